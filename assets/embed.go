@@ -9,3 +9,8 @@ var DefaultFont []byte
 
 //go:embed color_schemas/default.yaml
 var DefaultColorSchema []byte
+
+// TreeSchema JSON Schema документа --json-style nested
+//
+//go:embed schema/tree.v1.schema.json
+var TreeSchema []byte
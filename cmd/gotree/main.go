@@ -1,9 +1,11 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
@@ -13,8 +15,10 @@ import (
 	"syscall"
 
 	"github.com/atotto/clipboard"
+	"github.com/massonsky/gotree/assets"
 	"github.com/massonsky/gotree/internal/config"
 	"github.com/massonsky/gotree/internal/exporter"
+	"github.com/massonsky/gotree/internal/filter"
 	"github.com/massonsky/gotree/internal/logger"
 	"github.com/massonsky/gotree/internal/renderer"
 	"github.com/massonsky/gotree/internal/tree"
@@ -26,6 +30,9 @@ import (
 var appConfig *config.Config
 
 func getFormatFromExtension(filename string) exporter.Format {
+	if strings.HasSuffix(strings.ToLower(filename), ".tree.json") {
+		return exporter.FormatTree
+	}
 	ext := strings.ToLower(filepath.Ext(filename))
 	switch ext {
 	case ".png":
@@ -36,6 +43,14 @@ func getFormatFromExtension(filename string) exporter.Format {
 		return exporter.FormatJSON
 	case ".svg": // Добавили SVG
 		return exporter.FormatSVG
+	case ".html", ".htm":
+		return exporter.FormatHTML
+	case ".md", ".markdown":
+		return exporter.FormatMD
+	case ".xml":
+		return exporter.FormatXML
+	case ".ndjson", ".jsonl":
+		return exporter.FormatNDJSON
 	default:
 		if strings.Contains(strings.ToLower(filename), "json") {
 			return exporter.FormatJSON
@@ -82,20 +97,169 @@ func parseIgnorePatternsFromSlice(raw []string) []string {
 	return out
 }
 
+// applyFlags переносит флаги командной строки в конфиг
+func applyFlags(c *cli.Context) error {
+	if c.IsSet("depth") {
+		appConfig.MaxDepth = c.Int("depth")
+	}
+	if c.IsSet("filelimit") {
+		appConfig.FileLimit = c.Int("filelimit")
+	}
+	if c.IsSet("max-children") {
+		appConfig.MaxChildren = c.Int("max-children")
+	}
+	if c.IsSet("ignore") {
+		appConfig.IgnorePatterns = parseIgnorePatternsFromSlice(c.StringSlice("ignore"))
+	}
+	if c.IsSet("jobs") {
+		appConfig.Jobs = c.Int("jobs")
+	}
+	if c.IsSet("gitignore") {
+		appConfig.GitIgnore = c.Bool("gitignore")
+	}
+	if c.IsSet("follow-symlinks") {
+		appConfig.FollowSymlinks = c.Bool("follow-symlinks")
+	}
+	if c.IsSet("strict") {
+		appConfig.Strict = c.Bool("strict")
+	}
+	if c.IsSet("one-file-system") {
+		appConfig.OneFileSystem = c.Bool("one-file-system")
+	}
+	if c.IsSet("into-archives") {
+		appConfig.IntoArchives = c.Bool("into-archives")
+	}
+	if c.IsSet("sort") {
+		mode, err := tree.ParseSortMode(c.String("sort"))
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		appConfig.SortBy = string(mode)
+	}
+	if c.IsSet("dirs-first") {
+		appConfig.DirsFirst = c.Bool("dirs-first")
+	}
+	if c.IsSet("reverse") {
+		appConfig.SortReverse = c.Bool("reverse")
+	}
+	if c.IsSet("du") {
+		appConfig.DiskUsage = c.Bool("du")
+	}
+	if c.IsSet("links") {
+		appConfig.ShowLinks = c.Bool("links")
+	}
+	if c.IsSet("size-mode") {
+		mode, err := tree.ParseSizeMode(c.String("size-mode"))
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		appConfig.SizeMode = string(mode)
+	}
+	if c.IsSet("sniff") {
+		appConfig.SniffContent = c.Bool("sniff")
+	}
+	if c.IsSet("loc") {
+		appConfig.Loc = c.Bool("loc")
+	}
+	if c.IsSet("hash") {
+		appConfig.Hash = c.Bool("hash")
+	}
+	if c.IsSet("hash-algo") {
+		algo, err := tree.ParseHashAlgo(c.String("hash-algo"))
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		appConfig.HashAlgo = string(algo)
+	}
+	if c.IsSet("git-status") {
+		appConfig.GitStatus = c.Bool("git-status")
+	}
+	if c.IsSet("git-history") {
+		appConfig.GitHistory = c.Bool("git-history")
+	}
+	if c.IsSet("git-changed-only") {
+		appConfig.GitChangedOnly = c.Bool("git-changed-only")
+	}
+	if c.IsSet("where") {
+		if _, err := filter.Compile(c.String("where"), appConfig.Filters); err != nil {
+			return cli.Exit(fmt.Sprintf("--where: %v", err), 1)
+		}
+		appConfig.Where = c.String("where")
+	}
+	if c.IsSet("pattern") {
+		appConfig.IncludePatterns = tree.SplitPatterns(c.StringSlice("pattern"))
+	}
+	if c.IsSet("regex") {
+		for _, expr := range c.StringSlice("regex") {
+			if _, err := regexp.Compile(expr); err != nil {
+				return cli.Exit(fmt.Sprintf("--regex: %v", err), 1)
+			}
+		}
+		appConfig.IncludeRegex = c.StringSlice("regex")
+	}
+	if c.IsSet("matchdirs") {
+		appConfig.MatchDirs = c.Bool("matchdirs")
+	}
+	if c.IsSet("prune") {
+		appConfig.Prune = c.Bool("prune")
+	}
+	return nil
+}
+
+// exportFormat определяет формат экспорта: --format важнее расширения
+func exportFormat(c *cli.Context, exportPath string) exporter.Format {
+	if name := c.String("format"); name != "" {
+		return exporter.Format(strings.ToLower(name))
+	}
+	return getFormatFromExtension(exportPath)
+}
+
+// newExporter создаёт экспортер по расширению файла и флагам; root — путь
+// корня, как его передали (форматы GNU tree пишут его в имя корня)
+func newExporter(c *cli.Context, exportPath, root string) (exporter.Exporter, error) {
+	format := exportFormat(c, exportPath)
+	config := make(map[string]interface{})
+	config["templates_dir"] = appConfig.TemplatesDir
+	config["template"] = c.String("template")
+	config["disk_usage"] = appConfig.DiskUsage
+	config["links"] = appConfig.ShowLinks
+	config["git_status"] = appConfig.GitStatus || appConfig.GitChangedOnly
+	config["git_history"] = appConfig.GitHistory
+	config["root_name"] = root
+	config["json_style"] = c.String("json-style")
+	config["scan_config"] = appConfig
+	config["markdown_style"] = c.String("md-style")
+	config["markdown_metrics"] = c.Bool("md-metrics")
+
+	if fontPath := c.String("font"); fontPath != "" {
+		config["font_path"] = fontPath
+	}
+
+	return exporter.New(format, config)
+}
+
 // processDirectory — основная логика обработки директории
 func processDirectory(ctx context.Context, c *cli.Context, path string) error {
 	logger.Infof("Processing directory: %s", path)
 
 	// Применяем флаги в конфиг ДО старта обхода
-	if c.IsSet("depth") {
-		appConfig.MaxDepth = c.Int("depth")
+	if err := applyFlags(c); err != nil {
+		return err
 	}
-	if c.IsSet("ignore") {
-		appConfig.IgnorePatterns = parseIgnorePatternsFromSlice(c.StringSlice("ignore"))
+	if c.Bool("stream") {
+		if pathListName(c) != "" {
+			return cli.Exit("--stream cannot be combined with --fromfile or --stdin", 1)
+		}
+		return processStream(ctx, c, path)
+	}
+	// NDJSON пишется по мере обхода и без --stream, если ничто не требует
+	// дерева целиком; иначе, как и дерево из списка путей, его выгружает Export
+	if exportPath := c.String("export"); exportPath != "" && pathListName(c) == "" &&
+		exportFormat(c, exportPath) == exporter.FormatNDJSON && !needsWholeTree(c) {
+		return processStream(ctx, c, path)
 	}
 
-	showProgress := !c.Bool("no-progress")
-	walkResult, err := tree.WalkDirWithContext(ctx, path, appConfig, showProgress)
+	walkResult, err := scan(ctx, c, path)
 	if err != nil {
 		if err == context.Canceled {
 			logger.Info("Operation cancelled by user")
@@ -104,24 +268,13 @@ func processDirectory(ctx context.Context, c *cli.Context, path string) error {
 		logger.Errorf("WalkDir failed: %v", err)
 		return cli.Exit(err.Error(), 1)
 	}
+	defer walkResult.Close()
 	if c.IsSet("ignore") {
 		appConfig.IgnorePatterns = parseIgnorePatternsFromSlice(c.StringSlice("ignore"))
 	}
 	// ЭКСПОРТ В ФАЙЛ
 	if exportPath := c.String("export"); exportPath != "" {
-		format := getFormatFromExtension(exportPath)
-		config := make(map[string]interface{})
-		config["templates_dir"] = appConfig.TemplatesDir
-		config["template"] = c.String("template")
-
-		if c.String("font") != "" {
-			config["font_path"] = c.String("font")
-		}
-		if fontPath := c.String("font"); fontPath != "" {
-			config["font_path"] = fontPath
-		}
-
-		exporterImpl, err := exporter.New(format, config)
+		exporterImpl, err := newExporter(c, exportPath, path)
 		if err != nil {
 			return cli.Exit(fmt.Sprintf("Export error: %v", err), 1)
 		}
@@ -132,7 +285,7 @@ func processDirectory(ctx context.Context, c *cli.Context, path string) error {
 		}
 		defer file.Close()
 
-		if err := exporterImpl.Export(file, walkResult.Entries); err != nil {
+		if err := exporterImpl.Export(file, walkResult); err != nil {
 			return cli.Exit(fmt.Sprintf("Export failed: %v", err), 1)
 		}
 		if !c.Bool("no-metrics") {
@@ -147,11 +300,11 @@ func processDirectory(ctx context.Context, c *cli.Context, path string) error {
 	// но также печатаем стандартный цветной вывод в консоль.
 	if c.Bool("add-to-clipboard") {
 		// печатаем в консоль
-		renderer.PrintTree(walkResult.Entries, appConfig)
+		renderer.PrintTree(walkResult.Root, appConfig)
 
 		// рендерим в буфер и копируем (без ANSI)
 		var buf bytes.Buffer
-		renderer.PrintTreeToWriter(&buf, walkResult.Entries, appConfig)
+		renderer.PrintTreeToWriter(&buf, walkResult.Root, appConfig)
 
 		// удаляем ANSI-коды перед копированием
 		re := regexp.MustCompile(`\x1b\[[0-9;]*[a-zA-Z]`)
@@ -162,7 +315,7 @@ func processDirectory(ctx context.Context, c *cli.Context, path string) error {
 			logger.Info("Rendered tree copied to clipboard")
 		}
 	} else {
-		renderer.PrintTree(walkResult.Entries, appConfig)
+		renderer.PrintTree(walkResult.Root, appConfig)
 	}
 
 	if !c.Bool("no-metrics") {
@@ -173,6 +326,172 @@ func processDirectory(ctx context.Context, c *cli.Context, path string) error {
 	return nil
 }
 
+// processDupes ищет файлы с одинаковым содержимым и выводит их в контексте
+// дерева; при --export в файл попадает то же усечённое дерево с хэшами
+func processDupes(ctx context.Context, c *cli.Context, path string) error {
+	if err := applyFlags(c); err != nil {
+		return err
+	}
+	if c.Bool("stream") {
+		return cli.Exit("dupes needs the whole tree and cannot be combined with --stream", 1)
+	}
+
+	// Дубликаты ищутся по всему дереву, --max-children сокращает только
+	// итоговое дерево дубликатов (см. DuplicateTree)
+	limit := appConfig.MaxChildren
+	appConfig.MaxChildren = 0
+	walkResult, err := scan(ctx, c, path)
+	appConfig.MaxChildren = limit
+	if err != nil {
+		if err == context.Canceled {
+			logger.Info("Operation cancelled by user")
+			return nil
+		}
+		logger.Errorf("WalkDir failed: %v", err)
+		return cli.Exit(err.Error(), 1)
+	}
+	defer walkResult.Close()
+
+	logger.Infof("Looking for duplicates in %s", path)
+	groups, err := tree.FindDuplicates(ctx, walkResult.Root, appConfig)
+	if err != nil {
+		if err == context.Canceled {
+			logger.Info("Operation cancelled by user")
+			return nil
+		}
+		logger.Errorf("Duplicate search failed: %v", err)
+		return cli.Exit(err.Error(), 1)
+	}
+	dupRoot := tree.DuplicateTree(walkResult.Root, groups, appConfig)
+
+	if exportPath := c.String("export"); exportPath != "" {
+		exporterImpl, err := newExporter(c, exportPath, path)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("Export error: %v", err), 1)
+		}
+
+		file, err := os.Create(exportPath)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("Cannot create file %s: %v", exportPath, err), 1)
+		}
+		defer file.Close()
+
+		result := tree.WalkResult{Root: dupRoot, Entries: dupRoot.Flatten(), Metrics: walkResult.Metrics}
+		if err := exporterImpl.Export(file, result); err != nil {
+			return cli.Exit(fmt.Sprintf("Export failed: %v", err), 1)
+		}
+		logger.Infof("Exported %d duplicate group(s) to %s", len(groups), exportPath)
+	} else {
+		renderer.PrintDuplicates(os.Stdout, dupRoot, groups, appConfig)
+	}
+
+	if !c.Bool("no-metrics") {
+		renderer.PrintMetrics(walkResult.Metrics)
+	}
+	return nil
+}
+
+// pathListName возвращает файл со списком путей из --fromfile или "-"
+// для --stdin; "" означает обычный обход диска
+func pathListName(c *cli.Context) string {
+	if c.Bool("stdin") {
+		return "-"
+	}
+	return c.String("fromfile")
+}
+
+// scan строит дерево обходом директории или из списка путей
+func scan(ctx context.Context, c *cli.Context, path string) (tree.WalkResult, error) {
+	listName := pathListName(c)
+	if listName == "" {
+		return tree.WalkDirWithContext(ctx, path, appConfig, !c.Bool("no-progress"))
+	}
+
+	var r io.Reader = os.Stdin
+	if listName != "-" {
+		f, err := os.Open(listName)
+		if err != nil {
+			return tree.WalkResult{}, err
+		}
+		defer f.Close()
+		r = f
+	}
+	logger.Infof("Building tree from path list %s", listName)
+	return tree.BuildFromList(ctx, r, appConfig)
+}
+
+// needsWholeTree сообщает, включены ли опции, которым нужно всё дерево
+// после обхода и которые поэтому несовместимы с потоковым выводом
+func needsWholeTree(c *cli.Context) bool {
+	return appConfig.DiskUsage || appConfig.Prune || appConfig.Hash || appConfig.Loc ||
+		appConfig.GitStatus || appConfig.GitChangedOnly || c.Bool("add-to-clipboard")
+}
+
+// processStream выводит дерево по мере обхода, не держа его в памяти целиком
+func processStream(ctx context.Context, c *cli.Context, path string) error {
+	if appConfig.DiskUsage {
+		return cli.Exit("--du needs the whole tree and cannot be combined with --stream", 1)
+	}
+	if appConfig.Prune {
+		return cli.Exit("--prune needs the whole tree and cannot be combined with --stream", 1)
+	}
+	if appConfig.Hash {
+		return cli.Exit("--hash runs after the walk and cannot be combined with --stream", 1)
+	}
+	if appConfig.Loc {
+		return cli.Exit("--loc sums lines per directory after the walk and cannot be combined with --stream", 1)
+	}
+	if appConfig.GitStatus || appConfig.GitChangedOnly {
+		return cli.Exit("--git-status needs the whole tree and cannot be combined with --stream", 1)
+	}
+	if c.Bool("add-to-clipboard") {
+		return cli.Exit("--add-to-clipboard cannot be combined with --stream", 1)
+	}
+
+	stream := tree.NewStream(ctx, path, appConfig)
+	var err error
+	if exportPath := c.String("export"); exportPath != "" {
+		exporterImpl, expErr := newExporter(c, exportPath, path)
+		if expErr != nil {
+			return cli.Exit(fmt.Sprintf("Export error: %v", expErr), 1)
+		}
+		streamer, ok := exporterImpl.(exporter.StreamExporter)
+		if !ok {
+			return cli.Exit(fmt.Sprintf("Format of %s does not support --stream", exportPath), 1)
+		}
+
+		file, createErr := os.Create(exportPath)
+		if createErr != nil {
+			return cli.Exit(fmt.Sprintf("Cannot create file %s: %v", exportPath, createErr), 1)
+		}
+		defer file.Close()
+
+		// Буферизуем запись, чтобы не делать системный вызов на каждую строку
+		buf := bufio.NewWriter(file)
+		err = streamer.ExportStream(buf, stream)
+		if flushErr := buf.Flush(); err == nil {
+			err = flushErr
+		}
+	} else {
+		err = renderer.PrintStream(os.Stdout, stream, appConfig)
+	}
+
+	if err != nil {
+		if err == context.Canceled {
+			logger.Info("Operation cancelled by user")
+			return nil
+		}
+		logger.Errorf("Streaming walk failed: %v", err)
+		return cli.Exit(err.Error(), 1)
+	}
+
+	if !c.Bool("no-metrics") {
+		renderer.PrintMetrics(stream.Metrics())
+	}
+	logger.Infof("Successfully streamed tree for %s", path)
+	return nil
+}
+
 func main() {
 	// Загружаем конфиг
 	var err error
@@ -200,17 +519,55 @@ func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
+	if err := newApp(ctx).RunContext(ctx, os.Args); err != nil {
+		logger.Errorf("Application failed: %v", err)
+		os.Exit(1)
+	}
+}
+
+// newApp описывает команды и флаги CLI; appConfig должен быть загружен
+func newApp(ctx context.Context) *cli.App {
+	// Источник дерева вместо обхода диска
+	pathListFlags := []cli.Flag{
+		&cli.StringFlag{
+			Name:  "fromfile",
+			Usage: "Build the tree from newline- or NUL-separated paths in `FILE` (\"-\" for stdin); optional tab-separated size and mtime columns",
+		},
+		&cli.BoolFlag{
+			Name:  "stdin",
+			Usage: "Same as --fromfile -",
+		},
+	}
+
 	// Общие флаги для всех команд
 	commonFlags := []cli.Flag{
 		&cli.StringFlag{
 			Name:    "export",
 			Aliases: []string{"e"},
-			Usage:   "Export tree to file (supports: png, txt, json)",
+			Usage:   "Export tree to file (supports: png, txt, json, ndjson/jsonl, svg, html, md, xml; name.tree.json for GNU tree -J)",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Export format instead of guessing it from the file extension: png, txt, json, ndjson, tree-json, svg, html, markdown, xml",
 		},
 		&cli.StringFlag{
 			Name:  "font",
 			Usage: "Path to TTF font file for PNG export",
 		},
+		&cli.StringFlag{
+			Name:  "json-style",
+			Usage: "JSON export style: flat (array of entries with path and depth) or nested (versioned document with children, options, metrics and errors; see gotree schema)",
+			Value: string(exporter.JSONFlat),
+		},
+		&cli.StringFlag{
+			Name:  "md-style",
+			Usage: "Markdown export style: code (fenced block with tree connectors) or list (nested list with relative links)",
+			Value: string(exporter.MarkdownCode),
+		},
+		&cli.BoolFlag{
+			Name:  "md-metrics",
+			Usage: "Append a metrics table to the Markdown export",
+		},
 		&cli.BoolFlag{
 			Name:    "no-progress",
 			Aliases: []string{"np"},
@@ -228,19 +585,133 @@ func main() {
 			Usage: "Max depth of directory tree",
 			Value: 10, // значение по умолчанию
 		},
+		&cli.IntFlag{
+			Name:  "filelimit",
+			Usage: "Do not descend into directories with more than N entries; they are shown with their entry count",
+		},
+		&cli.IntFlag{
+			Name:  "max-children",
+			Usage: "Show at most N entries per directory followed by a summary line such as \"… 2 341 more files (120 MB)\"",
+		},
 		&cli.StringSliceFlag{
 			Name:    "ignore",
 			Aliases: []string{"I"},
 			Usage:   "Ignore paths matching pattern (can be used multiple times)",
 		},
+		&cli.StringFlag{
+			Name:  "where",
+			Usage: "Show only entries matching `EXPR`, e.g. 'size > 10MB && ext in (\"go\", \"mod\") && mtime < 30d && !hidden'; @name refers to a filter from the config",
+		},
+		&cli.StringSliceFlag{
+			Name:    "pattern",
+			Aliases: []string{"P"},
+			Usage:   "Show only files matching glob `PATTERN` and the directories leading to them (can be repeated, \"|\" separates alternatives)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "regex",
+			Usage: "Like --pattern, but `REGEX` is a regular expression matched against the name",
+		},
+		&cli.BoolFlag{
+			Name:  "matchdirs",
+			Usage: "Apply --pattern and --regex to directory names too; a matching directory is shown with all its contents",
+		},
+		&cli.BoolFlag{
+			Name:  "prune",
+			Usage: "Remove empty directories from the output",
+		},
+		&cli.BoolFlag{
+			Name:  "git-changed-only",
+			Usage: "Show only files with uncommitted changes (modified, staged, untracked) and the directories leading to them",
+		},
+		&cli.BoolFlag{
+			Name:  "gitignore",
+			Usage: "Respect .gitignore, .git/info/exclude, global git excludes and .gotreeignore",
+		},
+		&cli.BoolFlag{
+			Name:    "follow-symlinks",
+			Aliases: []string{"l"},
+			Usage:   "Follow symbolic links to directories (cycles are detected and not followed)",
+		},
+		&cli.BoolFlag{
+			Name:    "one-file-system",
+			Aliases: []string{"x"},
+			Usage:   "Do not descend into directories on other filesystems; mount points are still shown",
+		},
+		&cli.BoolFlag{
+			Name:  "into-archives",
+			Usage: "Show the contents of .zip, .jar, .tar and .tar.gz files found during the scan",
+		},
+		&cli.BoolFlag{
+			Name:  "strict",
+			Usage: "Abort on the first unreadable path instead of reporting it inline",
+		},
+		&cli.StringFlag{
+			Name:  "sort",
+			Usage: "Sort entries by name, natural, size, mtime, extension, or by git history: committed, commits, author",
+		},
+		&cli.BoolFlag{
+			Name:  "dirs-first",
+			Usage: "List directories before files",
+		},
+		&cli.BoolFlag{
+			Name:    "reverse",
+			Aliases: []string{"r"},
+			Usage:   "Reverse the sort order",
+		},
+		&cli.BoolFlag{
+			Name:  "du",
+			Usage: "Show cumulative size and file count next to directories",
+		},
+		&cli.BoolFlag{
+			Name:  "links",
+			Usage: "Show the hard link count next to files (hard-linked files are always counted once in sizes)",
+		},
+		&cli.BoolFlag{
+			Name:  "sniff",
+			Usage: "Detect file types from content (magic bytes, shebang, text or binary), not only from the extension",
+		},
+		&cli.BoolFlag{
+			Name:  "loc",
+			Usage: "Count code, comment and blank lines per file and directory and show a per-language summary, like cloc",
+		},
+		&cli.BoolFlag{
+			Name:  "git-status",
+			Usage: "Show the git status of each entry like eza --git: index and worktree letters (N new, M modified, D deleted, R renamed, T type change, U conflict, I ignored)",
+		},
+		&cli.BoolFlag{
+			Name:  "git-history",
+			Usage: "Show the last commit date, last author and number of commits next to each entry (one git log per run)",
+		},
+		&cli.BoolFlag{
+			Name:  "hash",
+			Usage: "Hash the content of every file after the walk (shown in JSON export)",
+		},
+		&cli.StringFlag{
+			Name:  "hash-algo",
+			Usage: "Content hash algorithm: sha256 or xxhash (faster, not cryptographic)",
+		},
+		&cli.StringFlag{
+			Name:  "size-mode",
+			Usage: "Measure sizes as apparent (file length) or blocks (allocated on disk)",
+		},
+		&cli.IntFlag{
+			Name:    "jobs",
+			Aliases: []string{"j"},
+			Usage:   "Number of parallel directory readers (0 or 1 = sequential)",
+		},
+		&cli.BoolFlag{
+			Name:  "stream",
+			Usage: "Print entries as they are discovered instead of building the whole tree in memory (console, txt and ndjson; ndjson export streams without it unless --du, --hash, --loc and the like need the whole tree)",
+		},
 		&cli.BoolFlag{
 			Name:  "add-to-clipboard",
 			Usage: "Copy rendered tree to clipboard after rendering",
 			Value: false,
 		},
 	}
+	commonFlags = append(commonFlags, pathListFlags...)
 
-	app := &cli.App{
+	return &cli.App{
 		Name:  "gotree",
 		Usage: "📁 Advanced directory tree visualizer",
 		Flags: commonFlags,
@@ -271,6 +742,14 @@ func main() {
 					},
 				},
 			},
+			{
+				Name:  "schema",
+				Usage: "print the JSON Schema of --json-style nested exports",
+				Action: func(c *cli.Context) error {
+					_, err := os.Stdout.Write(assets.TreeSchema)
+					return err
+				},
+			},
 			{
 				Name:    "run",
 				Aliases: []string{"r"},
@@ -284,18 +763,43 @@ func main() {
 					return processDirectory(ctx, c, path)
 				},
 			},
+			{
+				Name:      "dupes",
+				Usage:     "find duplicate files and show them in the tree",
+				ArgsUsage: "[path]",
+				Flags:     commonFlags,
+				Action: func(c *cli.Context) error {
+					path := "."
+					if c.Args().Present() {
+						path = c.Args().First()
+					}
+					return processDupes(ctx, c, path)
+				},
+			},
 			{
 				Name:    "interactive",
 				Aliases: []string{"i"},
 				Usage:   "interactive tree explorer",
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					&cli.BoolFlag{
 						Name:    "no-progress",
 						Aliases: []string{"np"},
 						Usage:   "Disable progress bar during initial scan",
 						Value:   false,
 					},
-				},
+					&cli.BoolFlag{
+						Name:  "git-status",
+						Usage: "Show the git status of each entry",
+					},
+					&cli.IntFlag{
+						Name:  "filelimit",
+						Usage: "Do not descend into directories with more than N entries",
+					},
+					&cli.IntFlag{
+						Name:  "max-children",
+						Usage: "Show at most N entries per directory followed by a summary line",
+					},
+				}, pathListFlags...),
 				Action: func(c *cli.Context) error {
 					path := "."
 					if c.Args().Present() {
@@ -304,6 +808,24 @@ func main() {
 
 					// Обновляем MaxDepth для интерактивного режима (больше глубины)
 					appConfig.MaxDepth = 20
+					if c.IsSet("git-status") {
+						appConfig.GitStatus = c.Bool("git-status")
+					}
+					if c.IsSet("filelimit") {
+						appConfig.FileLimit = c.Int("filelimit")
+					}
+					if c.IsSet("max-children") {
+						appConfig.MaxChildren = c.Int("max-children")
+					}
+
+					if pathListName(c) != "" {
+						result, err := scan(ctx, c, path)
+						if err != nil {
+							return cli.Exit(err.Error(), 1)
+						}
+						logger.Info("Starting interactive mode for path list")
+						return tui.RunResult(ctx, appConfig, result)
+					}
 
 					logger.Infof("Starting interactive mode for %s", path)
 					return tui.Run(ctx, appConfig, path)
@@ -311,9 +833,4 @@ func main() {
 			},
 		},
 	}
-
-	if err := app.RunContext(ctx, os.Args); err != nil {
-		logger.Errorf("Application failed: %v", err)
-		os.Exit(1)
-	}
 }
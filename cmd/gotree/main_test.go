@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/massonsky/gotree/internal/config"
+)
+
+// runApp запускает CLI с чистым конфигом, как после первого запуска
+func runApp(t *testing.T, args ...string) error {
+	t.Helper()
+	appConfig = config.DefaultConfig()
+	exiter := cli.OsExiter
+	cli.OsExiter = func(int) {} // ошибка возвращается из Run
+	t.Cleanup(func() { cli.OsExiter = exiter })
+	return newApp(context.Background()).Run(append([]string{"gotree"}, args...))
+}
+
+// readNDJSON разбирает записи ndjson-файла
+func readNDJSON(t *testing.T, filename string) []map[string]any {
+	t.Helper()
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var records []map[string]any
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func TestNDJSONExport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "root")
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string]string{"a.txt": "hello", "sub/b.txt": "world!"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		args      []string
+		totalSize bool // у директорий есть итоги поддерева: дерево строилось целиком
+	}{
+		{"streamed", nil, false},
+		{"disk usage", []string{"--du"}, true},
+		{"hash", []string{"--hash"}, true},
+		{"loc", []string{"--loc"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "tree.ndjson")
+			args := append([]string{"--no-progress", "--no-metrics", "-e", out}, tt.args...)
+			if err := runApp(t, append(args, dir)...); err != nil {
+				t.Fatalf("gotree %q: %v", args, err)
+			}
+
+			records := readNDJSON(t, out)
+			if len(records) != 5 {
+				t.Fatalf("got %d records, want 4 entries and the summary: %v", len(records), records)
+			}
+			if records[4]["type"] != "summary" {
+				t.Errorf("last record = %v, want summary", records[4])
+			}
+			_, hasTotal := records[0]["total_size"]
+			if hasTotal != tt.totalSize {
+				t.Errorf("root record %v: total_size present = %v, want %v", records[0], hasTotal, tt.totalSize)
+			}
+			if _, hasHash := records[1]["hash"]; hasHash != (tt.name == "hash") {
+				t.Errorf("file record %v: hash present = %v", records[1], hasHash)
+			}
+		})
+	}
+}
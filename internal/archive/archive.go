@@ -0,0 +1,106 @@
+package archive
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// Kind тип архива, определяемый по расширению
+type Kind string
+
+const (
+	KindZip   Kind = "zip"
+	KindTar   Kind = "tar"
+	KindTarGz Kind = "tar.gz"
+)
+
+// Detect определяет тип архива по имени файла. ok == false, если
+// gotree не умеет читать такие файлы.
+func Detect(name string) (Kind, bool) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"), strings.HasSuffix(lower, ".jar"):
+		return KindZip, true
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return KindTarGz, true
+	case strings.HasSuffix(lower, ".tar"):
+		return KindTar, true
+	default:
+		return "", false
+	}
+}
+
+// IsArchive сообщает, умеет ли gotree читать архив по имени файла
+func IsArchive(name string) bool {
+	_, ok := Detect(name)
+	return ok
+}
+
+// Open открывает архив на диске как файловую систему только для чтения.
+// Closer нужно закрыть, когда содержимое архива больше не нужно.
+func Open(filename string) (fs.FS, io.Closer, error) {
+	kind, ok := Detect(filename)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: unsupported archive format", filename)
+	}
+
+	switch kind {
+	case KindZip:
+		r, err := zip.OpenReader(filename)
+		if err != nil {
+			return nil, nil, err
+		}
+		return r, r, nil
+	default:
+		open := func() (io.ReadCloser, error) {
+			return openTarStream(filename, kind == KindTarGz)
+		}
+		fsys, err := newTarFS(open)
+		if err != nil {
+			return nil, nil, err
+		}
+		// tarFS открывает файл заново только для крупных файлов и ничего не держит открытым
+		return fsys, nopCloser{}, nil
+	}
+}
+
+// openTarStream открывает поток tar, при необходимости распаковывая gzip
+func openTarStream(filename string, gzipped bool) (io.ReadCloser, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	if !gzipped {
+		return f, nil
+	}
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &gzipFile{Reader: gz, file: f}, nil
+}
+
+// gzipFile закрывает и распаковщик, и исходный файл
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipFile) Close() error {
+	err := g.Reader.Close()
+	if fileErr := g.file.Close(); err == nil {
+		err = fileErr
+	}
+	return err
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,305 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// tarFS файловая система поверх tar-архива. При создании архив читается
+// один раз: небольшие файлы запоминаются целиком, так что хэши, --sniff
+// и --loc не распаковывают архив заново для каждого файла. Крупные файлы
+// сверх лимитов читаются повторным проходом по архиву при Open.
+type tarFS struct {
+	open    func() (io.ReadCloser, error)
+	entries map[string]*tarEntry
+	cached  int64 // байт содержимого в памяти
+}
+
+// Лимиты содержимого, которое tarFS держит в памяти
+const (
+	maxCachedMember = 1 << 20  // файл больше читается повторным проходом
+	maxCachedTotal  = 64 << 20 // на весь архив
+)
+
+// tarEntry элемент архива. Директории, которых нет в архиве явно,
+// но которые подразумеваются путями файлов, создаются без заголовка.
+type tarEntry struct {
+	name     string      // путь внутри архива без "./" и завершающего "/"
+	hdr      *tar.Header // nil для подразумеваемых директорий
+	index    int         // порядковый номер заголовка в архиве
+	children []*tarEntry // отсортированы по имени
+	link     *tarEntry   // цель жёсткой ссылки (TypeLink)
+	data     []byte      // содержимое, если оно запомнено при чтении архива
+	cached   bool
+}
+
+func newTarFS(open func() (io.ReadCloser, error)) (*tarFS, error) {
+	rc, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	t := &tarFS{
+		open:    open,
+		entries: map[string]*tarEntry{".": {name: "."}},
+	}
+
+	tr := tar.NewReader(rc)
+	for index := 0; ; index++ {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+
+		name := cleanName(hdr.Name)
+		if name == "." || !fs.ValidPath(name) {
+			continue
+		}
+		// Повторная запись с тем же именем заменяет предыдущую, как при распаковке
+		entry := t.ensure(name)
+		entry.hdr, entry.index = hdr, index
+		entry.data, entry.cached = nil, false
+		if hdr.Typeflag == tar.TypeReg && hdr.Size <= maxCachedMember && t.cached+hdr.Size <= maxCachedTotal {
+			if entry.data, err = io.ReadAll(tr); err != nil {
+				return nil, err
+			}
+			entry.cached = true
+			t.cached += hdr.Size
+		}
+	}
+
+	for _, entry := range t.entries {
+		entry.link = t.hardLinkTarget(entry)
+		sort.Slice(entry.children, func(i, j int) bool {
+			return entry.children[i].name < entry.children[j].name
+		})
+	}
+	return t, nil
+}
+
+func cleanName(name string) string {
+	name = strings.TrimPrefix(name, "./")
+	name = strings.TrimSuffix(name, "/")
+	if name == "" {
+		return "."
+	}
+	return path.Clean(name)
+}
+
+// ensure возвращает элемент по имени, создавая его и всех предков
+func (t *tarFS) ensure(name string) *tarEntry {
+	if entry, ok := t.entries[name]; ok {
+		return entry
+	}
+	entry := &tarEntry{name: name, index: -1}
+	t.entries[name] = entry
+	parent := t.ensure(path.Dir(name))
+	parent.children = append(parent.children, entry)
+	return entry
+}
+
+// hardLinkTarget находит файл, на который указывает жёсткая ссылка.
+// Linkname у жёстких ссылок задан от корня архива.
+func (t *tarFS) hardLinkTarget(entry *tarEntry) *tarEntry {
+	const maxHops = 40
+	target := entry
+	for hops := 0; target.hdr != nil && target.hdr.Typeflag == tar.TypeLink; hops++ {
+		next, ok := t.entries[cleanName(strings.TrimPrefix(target.hdr.Linkname, "/"))]
+		if !ok || hops == maxHops {
+			return nil
+		}
+		target = next
+	}
+	if target == entry || target.isDir() {
+		return nil
+	}
+	return target
+}
+
+func (e *tarEntry) isDir() bool {
+	return e.hdr == nil || e.hdr.Typeflag == tar.TypeDir || len(e.children) > 0
+}
+
+func (e *tarEntry) info() fs.FileInfo {
+	if e.hdr == nil || (len(e.children) > 0 && e.hdr.Typeflag != tar.TypeDir) {
+		return dirInfo{name: path.Base(e.name)}
+	}
+	if e.link != nil {
+		// Размер и права у жёсткой ссылки — как у файла, на который она указывает
+		return linkInfo{FileInfo: e.link.info(), name: path.Base(e.name)}
+	}
+	return e.hdr.FileInfo()
+}
+
+// lookup находит элемент без разыменования ссылок
+func (t *tarFS) lookup(op, name string) (*tarEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	entry, ok := t.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return entry, nil
+}
+
+// resolve находит элемент, переходя по символическим ссылкам внутри архива
+func (t *tarFS) resolve(op, name string) (*tarEntry, error) {
+	const maxHops = 40
+	entry, err := t.lookup(op, name)
+	for hops := 0; err == nil && entry.hdr != nil && entry.hdr.Typeflag == tar.TypeSymlink; hops++ {
+		if hops == maxHops {
+			return nil, &fs.PathError{Op: op, Path: name, Err: errors.New("too many levels of symbolic links")}
+		}
+		target := entry.hdr.Linkname
+		if !path.IsAbs(target) {
+			target = path.Join(path.Dir(entry.name), target)
+		}
+		entry, err = t.lookup(op, cleanName(strings.TrimPrefix(target, "/")))
+	}
+	return entry, err
+}
+
+// Open реализует fs.FS
+func (t *tarFS) Open(name string) (fs.File, error) {
+	entry, err := t.resolve("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if entry.isDir() {
+		return &tarDir{entry: entry}, nil
+	}
+	info := entry.info()
+	if entry.link != nil {
+		entry = entry.link
+	}
+	if entry.cached {
+		return &tarFile{info: info, Reader: bytes.NewReader(entry.data), closer: nopCloser{}}, nil
+	}
+
+	rc, err := t.open()
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(rc)
+	for index := 0; ; index++ {
+		if _, err := tr.Next(); err != nil {
+			rc.Close()
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		if index == entry.index {
+			return &tarFile{info: info, Reader: tr, closer: rc}, nil
+		}
+	}
+}
+
+// ReadDir реализует fs.ReadDirFS
+func (t *tarFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entry, err := t.resolve("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !entry.isDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	return (&tarDir{entry: entry}).ReadDir(-1)
+}
+
+// Stat реализует fs.StatFS, переходя по ссылкам
+func (t *tarFS) Stat(name string) (fs.FileInfo, error) {
+	entry, err := t.resolve("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return entry.info(), nil
+}
+
+// ReadLink возвращает цель символической ссылки внутри архива
+func (t *tarFS) ReadLink(name string) (string, error) {
+	entry, err := t.lookup("readlink", name)
+	if err != nil {
+		return "", err
+	}
+	if entry.hdr == nil || entry.hdr.Typeflag != tar.TypeSymlink {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return entry.hdr.Linkname, nil
+}
+
+// tarFile открытый файл архива
+type tarFile struct {
+	io.Reader
+	info   fs.FileInfo
+	closer io.Closer
+}
+
+func (f *tarFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *tarFile) Close() error               { return f.closer.Close() }
+
+// tarDir открытая директория архива
+type tarDir struct {
+	entry  *tarEntry
+	offset int
+}
+
+func (d *tarDir) Stat() (fs.FileInfo, error) { return d.entry.info(), nil }
+func (d *tarDir) Close() error               { return nil }
+
+func (d *tarDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.entry.name, Err: errors.New("is a directory")}
+}
+
+// ReadDir реализует fs.ReadDirFile
+func (d *tarDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entry.children[d.offset:]
+	if n > 0 && len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > 0 && len(rest) > n {
+		rest = rest[:n]
+	}
+	d.offset += len(rest)
+
+	entries := make([]fs.DirEntry, len(rest))
+	for i, child := range rest {
+		entries[i] = fs.FileInfoToDirEntry(child.info())
+	}
+	return entries, nil
+}
+
+// linkInfo сведения о жёсткой ссылке: файл цели под именем ссылки
+type linkInfo struct {
+	fs.FileInfo
+	name string
+}
+
+func (l linkInfo) Name() string { return l.name }
+
+// dirInfo сведения о директории, которой нет в архиве явно
+type dirInfo struct {
+	name string
+}
+
+func (d dirInfo) Name() string       { return d.name }
+func (d dirInfo) Size() int64        { return 0 }
+func (d dirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0o755 }
+func (d dirInfo) ModTime() time.Time { return time.Time{} }
+func (d dirInfo) IsDir() bool        { return true }
+func (d dirInfo) Sys() any           { return nil }
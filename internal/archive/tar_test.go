@@ -0,0 +1,132 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+// tarMember элемент тестового архива
+type tarMember struct {
+	name     string
+	typeflag byte
+	data     string
+	linkname string
+}
+
+// newTestTarFS собирает архив в памяти и считает, сколько раз его открывали
+func newTestTarFS(t *testing.T, members []tarMember) (*tarFS, *int) {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, m := range members {
+		hdr := &tar.Header{Name: m.name, Typeflag: m.typeflag, Linkname: m.linkname, Mode: 0o644}
+		if m.typeflag == tar.TypeReg {
+			hdr.Size = int64(len(m.data))
+		}
+		if m.typeflag == tar.TypeDir {
+			hdr.Mode = 0o755
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(tw, m.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	opens := 0
+	fsys, err := newTarFS(func() (io.ReadCloser, error) {
+		opens++
+		return io.NopCloser(bytes.NewReader(buf.Bytes())), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fsys, &opens
+}
+
+func TestTarFSFiles(t *testing.T) {
+	big := strings.Repeat("x", maxCachedMember+1)
+	fsys, opens := newTestTarFS(t, []tarMember{
+		{name: "a.txt", typeflag: tar.TypeReg, data: "hello"},
+		{name: "dir/", typeflag: tar.TypeDir},
+		{name: "dir/hard", typeflag: tar.TypeLink, linkname: "a.txt"},
+		{name: "dir/hard2", typeflag: tar.TypeLink, linkname: "./dir/hard"},
+		{name: "dir/sym", typeflag: tar.TypeSymlink, linkname: "../a.txt"},
+		{name: "big.bin", typeflag: tar.TypeReg, data: big},
+		{name: "empty", typeflag: tar.TypeReg},
+	})
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"a.txt", "hello"},
+		{"dir/hard", "hello"},
+		{"dir/hard2", "hello"},
+		{"dir/sym", "hello"},
+		{"empty", ""},
+		{"big.bin", big},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := fs.ReadFile(fsys, tt.name)
+			if err != nil {
+				t.Fatalf("ReadFile: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("content = %.20q (%d bytes), want %.20q (%d bytes)", data, len(data), tt.want, len(tt.want))
+			}
+
+			info, err := fs.Stat(fsys, tt.name)
+			if err != nil {
+				t.Fatalf("Stat: %v", err)
+			}
+			if info.Size() != int64(len(tt.want)) {
+				t.Errorf("size = %d, want %d", info.Size(), len(tt.want))
+			}
+		})
+	}
+
+	// Индексация и одно повторное чтение крупного файла
+	if *opens != 2 {
+		t.Errorf("archive opened %d times, want 2", *opens)
+	}
+}
+
+func TestTarFSHardLinkEntry(t *testing.T) {
+	fsys, _ := newTestTarFS(t, []tarMember{
+		{name: "a.txt", typeflag: tar.TypeReg, data: "hello"},
+		{name: "b.txt", typeflag: tar.TypeLink, linkname: "a.txt"},
+		{name: "dangling", typeflag: tar.TypeLink, linkname: "missing"},
+	})
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizes := map[string]int64{}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !info.Mode().IsRegular() {
+			t.Errorf("%s: mode %v, want regular file", e.Name(), info.Mode())
+		}
+		sizes[e.Name()] = info.Size()
+	}
+	want := map[string]int64{"a.txt": 5, "b.txt": 5, "dangling": 0}
+	for name, size := range want {
+		if sizes[name] != size {
+			t.Errorf("%s: size %d, want %d", name, sizes[name], size)
+		}
+	}
+}
@@ -22,11 +22,55 @@ type Config struct {
 	ImageHeight int `yaml:"image_height"`
 
 	// CLI-настройки
-	ShowHiddenFiles bool     `yaml:"show_hidden_files"`
-	MaxDepth        int      `yaml:"max_depth"`
-	IgnorePatterns  []string `yaml:"ignore_patterns"`
-	TemplatesDir    string   `yaml:"templates_dir"`
-	CurrentTemplate string   `yaml:"current_template"`
+	ShowHiddenFiles bool              `yaml:"show_hidden_files"`
+	MaxDepth        int               `yaml:"max_depth"`
+	IgnorePatterns  []string          `yaml:"ignore_patterns"`
+	Where           string            `yaml:"where"`            // выражение --where, см. пакет filter
+	Filters         map[string]string `yaml:"filters"`          // именованные выражения, доступные как @name
+	IncludePatterns []string          `yaml:"include_patterns"` // показывать только файлы по glob (--pattern)
+	IncludeRegex    []string          `yaml:"include_regex"`    // то же по регулярному выражению (--regex)
+	MatchDirs       bool              `yaml:"match_dirs"`       // шаблоны применяются и к директориям
+	Prune           bool              `yaml:"prune"`            // убирать пустые директории
+	FileLimit       int               `yaml:"filelimit"`        // не спускаться в директории, где записей больше; 0 — без ограничения
+	MaxChildren     int               `yaml:"max_children"`     // показывать не больше стольких детей директории, остальные — одной строкой
+	TemplatesDir    string            `yaml:"templates_dir"`
+	CurrentTemplate string            `yaml:"current_template"`
+
+	// Параметры обхода
+	Jobs      int  `yaml:"jobs"`      // число параллельных воркеров, 0 или 1 — последовательно
+	GitIgnore bool `yaml:"gitignore"` // учитывать .gitignore, .git/info/exclude и .gotreeignore
+
+	FollowSymlinks bool `yaml:"follow_symlinks"` // спускаться в директории по символическим ссылкам
+	Strict         bool `yaml:"strict"`          // прерывать обход на первой ошибке чтения
+	IntoArchives   bool `yaml:"into_archives"`   // раскрывать .zip, .jar, .tar и .tar.gz как директории
+	OneFileSystem  bool `yaml:"one_file_system"` // не переходить в другие файловые системы
+
+	SkipFSTypes []string `yaml:"skip_fs_types"` // типы файловых систем, в которые не спускаться: proc, sysfs, fuse.sshfs
+
+	// Сортировка вывода
+	SortBy      string `yaml:"sort_by"`      // name, natural, size, mtime, extension, committed, commits, author
+	DirsFirst   bool   `yaml:"dirs_first"`   // директории перед файлами
+	SortReverse bool   `yaml:"sort_reverse"` // обратный порядок
+
+	// Размеры
+	DiskUsage bool   `yaml:"disk_usage"` // показывать суммарный размер директорий (du)
+	SizeMode  string `yaml:"size_mode"`  // apparent — длина файла, blocks — место на диске
+	ShowLinks bool   `yaml:"show_links"` // показывать число жёстких ссылок у файлов
+
+	// Определение типа файлов: по расширению всегда, по содержимому — если включено
+	SniffContent bool `yaml:"sniff_content"` // читать первые байты файлов (сигнатуры, shebang, текст/двоичный)
+
+	// Подсчёт строк исходного кода, как cloc
+	Loc bool `yaml:"loc"` // считать строки кода, комментариев и пустые по языкам
+
+	// Хэширование содержимого
+	Hash     bool   `yaml:"hash"`      // считать хэш каждого файла после обхода
+	HashAlgo string `yaml:"hash_algo"` // sha256 или xxhash
+
+	// Git
+	GitStatus      bool `yaml:"git_status"`       // показывать состояние файлов в git
+	GitChangedOnly bool `yaml:"git_changed_only"` // показывать только изменённые файлы
+	GitHistory     bool `yaml:"git_history"`      // показывать последний коммит, автора и число коммитов
 }
 
 // DefaultConfig возвращает конфигурацию по умолчанию
@@ -40,6 +84,10 @@ func DefaultConfig() *Config {
 		MaxDepth:        10,
 		TemplatesDir:    filepath.Join(GetAssetsDir(), "templates"),
 		CurrentTemplate: "default",
+		Jobs:            1,
+		SortBy:          "name",
+		SizeMode:        "apparent",
+		HashAlgo:        "sha256",
 	}
 }
 
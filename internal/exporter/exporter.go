@@ -3,40 +3,174 @@ package exporter
 import (
 	"fmt"
 	"io"
+	"os"
+	"time"
 
-	"github.com/massonsky/gotree/internal/types"
+	"github.com/massonsky/gotree/internal/filetype"
+	"github.com/massonsky/gotree/internal/metrics"
+	"github.com/massonsky/gotree/internal/tree"
 )
 
 // Exporter интерфейс для всех форматов экспорта
 type Exporter interface {
-	Export(w io.Writer, entries []types.Entry) error
+	Export(w io.Writer, result tree.WalkResult) error
+}
+
+// StreamExporter экспортер, который пишет записи по мере обхода,
+// не дожидаясь построения всего дерева
+type StreamExporter interface {
+	ExportStream(w io.Writer, stream *tree.Stream) error
 }
 
 // Format поддерживаемые форматы
 type Format string
 
 const (
-	FormatPNG  Format = "png"
-	FormatTXT  Format = "txt"
-	FormatJSON Format = "json"
-	FormatSVG  Format = "svg" // Добавили SVG
+	FormatPNG    Format = "png"
+	FormatTXT    Format = "txt"
+	FormatJSON   Format = "json"
+	FormatSVG    Format = "svg" // Добавили SVG
+	FormatHTML   Format = "html"
+	FormatMD     Format = "markdown"
+	FormatXML    Format = "xml"       // как tree -X
+	FormatTree   Format = "tree-json" // как tree -J
+	FormatNDJSON Format = "ndjson"    // запись на строку, пишется по мере обхода
 )
 
+// Options параметры отображения, общие для экспортеров
+type Options struct {
+	DiskUsage  bool // показывать суммарный размер директорий
+	Links      bool // показывать число жёстких ссылок у файлов
+	GitStatus  bool // показывать колонку состояния git
+	GitHistory bool // показывать последний коммит, автора и число коммитов
+
+	RootName string // корень, как его передали в командной строке ("" — имя корня)
+}
+
+// optionsFromConfig читает общие параметры из конфигурации экспорта
+func optionsFromConfig(config map[string]interface{}) Options {
+	var opts Options
+	opts.DiskUsage, _ = config["disk_usage"].(bool)
+	opts.Links, _ = config["links"].(bool)
+	opts.GitStatus, _ = config["git_status"].(bool)
+	opts.GitHistory, _ = config["git_history"].(bool)
+	opts.RootName, _ = config["root_name"].(string)
+	return opts
+}
+
 // New создает экспортер по формату
 func New(format Format, config map[string]interface{}) (Exporter, error) {
+	opts := optionsFromConfig(config)
 	switch format {
 	case FormatPNG:
 		return NewPNGExporter(config)
 	case FormatTXT:
-		return &TextExporter{}, nil
+		return &TextExporter{opts: opts}, nil
 	case FormatJSON:
-		return &JSONExporter{}, nil
+		return NewJSONExporter(config)
 	case FormatSVG:
-		return &SVGExporter{}, nil
+		return &SVGExporter{opts: opts}, nil
+	case FormatHTML:
+		return &HTMLExporter{opts: opts}, nil
+	case FormatMD:
+		return NewMarkdownExporter(config)
+	case FormatXML:
+		return &XMLExporter{opts: opts}, nil
+	case FormatTree:
+		return &TreeJSONExporter{opts: opts}, nil
+	case FormatNDJSON:
+		return &NDJSONExporter{}, nil
 	default:
-		return nil, ErrUnsupportedFormat
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedFormat, format)
 	}
 }
 
 // ErrUnsupportedFormat is returned when an unsupported export format is requested.
 var ErrUnsupportedFormat = fmt.Errorf("unsupported export format")
+
+// countNodes возвращает количество строк, которое займёт дерево. Считаются
+// узлы, а не агрегаты: скрытые --max-children записи строк не занимают.
+func countNodes(root *tree.Node) int {
+	n := 0
+	_ = root.Walk(func(*tree.Node) error {
+		n++
+		return nil
+	})
+	return n
+}
+
+// sizeLabel возвращает подпись размера: для файлов — собственный размер,
+// для директорий в режиме du — размер и число файлов поддерева
+func sizeLabel(node *tree.Node, opts Options) string {
+	if node.IsDir() {
+		if opts.DiskUsage {
+			return fmt.Sprintf(" (%s, %s)", formatSize(node.Size), node.FilesLabel())
+		}
+		return ""
+	}
+	// У нераскрытой ссылки размера нет
+	if node.Info.Mode()&os.ModeSymlink != 0 {
+		return ""
+	}
+	if opts.Links && node.Links > 0 {
+		return fmt.Sprintf(" (%s, %s)", formatSize(node.Size), node.LinksLabel())
+	}
+	return fmt.Sprintf(" (%s)", formatSize(node.Size))
+}
+
+// gitLabel возвращает колонку состояния git перед строкой дерева.
+// Вне репозитория колонки нет, записи внутри архивов получают пустую.
+func gitLabel(node *tree.Node, opts Options) string {
+	if !opts.GitStatus || node.Root().Git == "" {
+		return ""
+	}
+	if node.Git == "" {
+		return "   "
+	}
+	return node.Git + " "
+}
+
+// entryIcon возвращает значок записи: по виду файла, для директорий,
+// архивов и ссылок — свои
+func entryIcon(node *tree.Node) string {
+	switch {
+	case node.IsSymlink():
+		return "🔗"
+	case node.Archive:
+		return "📦"
+	case node.IsDir():
+		return "📁"
+	default:
+		return filetype.Icon(filetype.Kind(node.Kind))
+	}
+}
+
+// metricRow строка таблицы метрик
+type metricRow struct {
+	Label string
+	Value string
+}
+
+// metricRows строки таблицы метрик для HTML и Markdown,
+// как в renderer.PrintMetrics
+func metricRows(m metrics.Metrics) []metricRow {
+	rows := []metricRow{
+		{"Files", fmt.Sprint(m.TotalFiles)},
+		{"Directories", fmt.Sprint(m.TotalDirs)},
+		{"Total size", metrics.FormatSize(m.TotalSize)},
+		{"Max depth", fmt.Sprint(m.MaxDepth)},
+	}
+	if m.HardLinks > 0 {
+		rows = append(rows, metricRow{"Hard links", fmt.Sprintf("%d (counted once)", m.HardLinks)})
+	}
+	if m.Errors > 0 {
+		rows = append(rows, metricRow{"Errors", fmt.Sprint(m.Errors)})
+	}
+	if m.ScanDuration > 0 {
+		rows = append(rows, metricRow{"Duration", m.ScanDuration.Truncate(time.Microsecond).String()})
+	}
+	if m.Workers > 1 {
+		rows = append(rows, metricRow{"Workers", fmt.Sprintf("%d (%.1fx speed-up)", m.Workers, m.SpeedUp)})
+	}
+	return rows
+}
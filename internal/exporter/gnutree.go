@@ -0,0 +1,234 @@
+package exporter
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/massonsky/gotree/internal/tree"
+)
+
+// Форматы, совместимые с GNU tree: tree -X и tree -J. Иерархия вложенная,
+// в конце идёт report с числом директорий (без корня) и файлов.
+// Сводки --max-children пропускаются, раскрытые архивы выглядят как
+// директории. Размер пишется у файлов, а у директорий — в режиме du,
+// как tree --du.
+
+// XMLExporter пишет дерево в формате tree -X
+type XMLExporter struct {
+	opts Options
+}
+
+// TreeJSONExporter пишет дерево в формате tree -J
+type TreeJSONExporter struct {
+	opts Options
+}
+
+// gnuType тип записи в терминах GNU tree: directory, file, link, fifo…
+func gnuType(node *tree.Node) string {
+	if node.IsSymlink() {
+		return "link" // с --follow-symlinks у ссылки на директорию есть contents
+	}
+	if node.IsDir() || node.Archive {
+		return "directory"
+	}
+	mode := node.Info.Mode()
+	switch {
+	case mode&fs.ModeSymlink != 0:
+		return "link"
+	case mode&fs.ModeNamedPipe != 0:
+		return "fifo"
+	case mode&fs.ModeSocket != 0:
+		return "socket"
+	case mode&fs.ModeCharDevice != 0:
+		return "char"
+	case mode&fs.ModeDevice != 0:
+		return "block"
+	default:
+		return "file"
+	}
+}
+
+// gnuError текст ошибки, как у GNU tree: "opening dir"
+func gnuError(node *tree.Node) string {
+	if node.OverLimit > 0 {
+		return strings.TrimSpace(strings.Trim(node.LimitLabel(), " []"))
+	}
+	if node.Err == nil {
+		return ""
+	}
+	var scanErr *tree.ScanError
+	if errors.As(node.Err, &scanErr) {
+		label := strings.Trim(scanErr.Label(), "[]")
+		return strings.TrimPrefix(label, "error ")
+	}
+	return "error"
+}
+
+// gnuName имя записи: у корня — путь, как его передали (tree -X . пишет
+// name="."), а без него — имя корня
+func gnuName(node *tree.Node, opts Options) string {
+	if node.IsRoot() {
+		if opts.RootName != "" {
+			return opts.RootName
+		}
+		return node.Path
+	}
+	return node.Name()
+}
+
+// gnuSize сообщает, писать ли размер записи
+func gnuSize(node *tree.Node, opts Options) bool {
+	if gnuType(node) == "directory" {
+		return opts.DiskUsage
+	}
+	return gnuType(node) != "link"
+}
+
+// gnuReport итоги для report: корень в директории не входит
+func gnuReport(result tree.WalkResult) (dirs, files int) {
+	dirs, files = result.Metrics.TotalDirs, result.Metrics.TotalFiles
+	if result.Root.IsDir() && dirs > 0 {
+		dirs--
+	}
+	return dirs, files
+}
+
+func (e *XMLExporter) Export(w io.Writer, result tree.WalkResult) error {
+	if result.Root == nil {
+		return nil
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	treeTag := xml.StartElement{Name: xml.Name{Local: "tree"}}
+	if err := enc.EncodeToken(treeTag); err != nil {
+		return err
+	}
+	if err := e.encode(enc, result.Root); err != nil {
+		return err
+	}
+
+	dirs, files := gnuReport(result)
+	report := struct {
+		XMLName     xml.Name `xml:"report"`
+		Size        *int64   `xml:"size,omitempty"`
+		Directories int      `xml:"directories"`
+		Files       int      `xml:"files"`
+	}{Directories: dirs, Files: files}
+	if e.opts.DiskUsage {
+		report.Size = &result.Root.Size
+	}
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+
+	if err := enc.EncodeToken(treeTag.End()); err != nil {
+		return err
+	}
+	if err := enc.Flush(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// encode пишет элемент записи и вложенные элементы детей
+func (e *XMLExporter) encode(enc *xml.Encoder, node *tree.Node) error {
+	if node.Omitted > 0 {
+		return nil
+	}
+
+	start := xml.StartElement{
+		Name: xml.Name{Local: gnuType(node)},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "name"}, Value: gnuName(node, e.opts)}},
+	}
+	if node.IsSymlink() {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "target"}, Value: node.LinkTarget})
+	}
+	if gnuSize(node, e.opts) {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "size"}, Value: strconv.FormatInt(node.Size, 10)})
+	}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	if msg := gnuError(node); msg != "" {
+		if err := enc.EncodeElement(msg, xml.StartElement{Name: xml.Name{Local: "error"}}); err != nil {
+			return err
+		}
+	}
+	for _, child := range node.Children {
+		if err := e.encode(enc, child); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// treeJSONEntry запись tree -J; порядок полей как у GNU tree
+type treeJSONEntry struct {
+	Type     string           `json:"type"`
+	Name     string           `json:"name"`
+	Target   string           `json:"target,omitempty"`
+	Size     *int64           `json:"size,omitempty"`
+	Error    string           `json:"error,omitempty"`
+	Contents *[]treeJSONEntry `json:"contents,omitempty"` // у директорий, даже пустых, и раскрытых ссылок
+}
+
+// treeJSONReport завершающая запись tree -J
+type treeJSONReport struct {
+	Type        string `json:"type"`
+	Size        *int64 `json:"size,omitempty"`
+	Directories int    `json:"directories"`
+	Files       int    `json:"files"`
+}
+
+func (e *TreeJSONExporter) Export(w io.Writer, result tree.WalkResult) error {
+	if result.Root == nil {
+		return nil
+	}
+
+	dirs, files := gnuReport(result)
+	report := treeJSONReport{Type: "report", Directories: dirs, Files: files}
+	if e.opts.DiskUsage {
+		report.Size = &result.Root.Size
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode([]any{e.entry(result.Root), report})
+}
+
+// entry переводит поддерево в записи tree -J
+func (e *TreeJSONExporter) entry(node *tree.Node) treeJSONEntry {
+	entry := treeJSONEntry{
+		Type:  gnuType(node),
+		Name:  gnuName(node, e.opts),
+		Error: gnuError(node),
+	}
+	if node.IsSymlink() {
+		entry.Target = node.LinkTarget
+	}
+	if gnuSize(node, e.opts) {
+		entry.Size = &node.Size
+	}
+	if entry.Type != "directory" && len(node.Children) == 0 {
+		return entry
+	}
+	contents := []treeJSONEntry{}
+	for _, child := range node.Children {
+		if child.Omitted == 0 {
+			contents = append(contents, e.entry(child))
+		}
+	}
+	entry.Contents = &contents
+	return entry
+}
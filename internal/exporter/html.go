@@ -0,0 +1,142 @@
+package exporter
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/massonsky/gotree/internal/metrics"
+	"github.com/massonsky/gotree/internal/tree"
+)
+
+//go:embed html.tmpl
+var htmlTemplate string
+
+// htmlPage шаблон страницы; CSS и JS встроены, внешних запросов нет
+var htmlPage = template.Must(template.New("page").Parse(htmlTemplate))
+
+// HTMLExporter пишет один автономный HTML-файл: сворачиваемые директории,
+// поиск, колонки размера и времени изменения, сортировка и метрики обхода
+type HTMLExporter struct {
+	opts Options
+}
+
+// htmlData данные шаблона страницы
+type htmlData struct {
+	Title     string
+	Generated string
+	Root      htmlNode
+	htmlColumns
+
+	Metrics   []metricRow
+	Languages []metrics.Language
+}
+
+// htmlColumns необязательные колонки: есть, только если данные собирались
+type htmlColumns struct {
+	Git     bool
+	History bool
+	Loc     bool
+}
+
+// htmlNode строка дерева
+type htmlNode struct {
+	Name     string
+	Icon     string
+	Suffix   string // пометки: цель ссылки, ошибка, точка монтирования
+	Class    string // dir, file, link, broken, omitted, error
+	Dir      bool
+	Indent   int // отступ имени в пикселях
+	Order    int // позиция в исходном порядке, для сброса сортировки
+	Size     int64
+	SizeStr  string
+	MTime    int64 // unix-время, 0 — неизвестно
+	MTimeStr string
+
+	Git       string
+	Commit    int64
+	CommitStr string
+	Code      int
+	CodeStr   string
+
+	Cols     *htmlColumns
+	Children []htmlNode
+}
+
+func (e *HTMLExporter) Export(w io.Writer, result tree.WalkResult) error {
+	if result.Root == nil {
+		return fmt.Errorf("no entries to export")
+	}
+
+	data := htmlData{
+		Title:     result.Root.Path,
+		Generated: time.Now().Format("2006-01-02 15:04:05"),
+		htmlColumns: htmlColumns{
+			Git:     e.opts.GitStatus && result.Root.Git != "",
+			History: e.opts.GitHistory,
+			Loc:     result.Root.Loc.Files > 0,
+		},
+		Metrics:   metricRows(result.Metrics),
+		Languages: result.Metrics.Languages,
+	}
+	order := 0
+	data.Root = newHTMLNode(result.Root, &data.htmlColumns, &order)
+	return htmlPage.Execute(w, data)
+}
+
+// newHTMLNode переводит поддерево в строки страницы
+func newHTMLNode(node *tree.Node, cols *htmlColumns, order *int) htmlNode {
+	h := htmlNode{
+		Name:    node.Name(),
+		Icon:    entryIcon(node),
+		Suffix:  strings.TrimSpace(node.Suffix()),
+		Dir:     node.IsDir() || node.Archive,
+		Indent:  node.Depth * 18,
+		Order:   *order,
+		Size:    node.Size,
+		SizeStr: formatSize(node.Size),
+		Git:     node.Git,
+		Cols:    cols,
+	}
+	*order++
+
+	switch {
+	case node.Omitted > 0:
+		h.Class = "omitted"
+	case node.Err != nil:
+		h.Class = "error"
+	case node.Broken:
+		h.Class = "broken"
+	case node.IsSymlink():
+		h.Class = "link"
+	case h.Dir:
+		h.Class = "dir"
+	default:
+		h.Class = "file"
+	}
+	// У нераскрытой ссылки размера нет
+	if node.Info.Mode()&os.ModeSymlink != 0 {
+		h.SizeStr = ""
+	}
+	if mtime := node.Info.ModTime(); !mtime.IsZero() && node.Omitted == 0 {
+		h.MTime = mtime.Unix()
+		h.MTimeStr = mtime.Format("2006-01-02 15:04")
+	}
+	if node.Commits > 0 {
+		h.Commit = node.LastCommit.Unix()
+		h.CommitStr = strings.TrimSpace(strings.Trim(node.HistoryLabel(), " []"))
+	}
+	if node.Loc.Files > 0 {
+		h.Code = node.Loc.Code
+		h.CodeStr = fmt.Sprint(node.Loc.Code)
+	}
+
+	for _, child := range node.Children {
+		h.Children = append(h.Children, newHTMLNode(child, cols, order))
+	}
+	return h
+}
@@ -2,15 +2,55 @@ package exporter
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
-	"github.com/massonsky/gotree/internal/types"
+	"github.com/massonsky/gotree/internal/config"
+	"github.com/massonsky/gotree/internal/loc"
+	"github.com/massonsky/gotree/internal/metrics"
+	"github.com/massonsky/gotree/internal/tree"
 )
 
-type JSONExporter struct{}
+// JSONStyle вид JSON-экспорта
+type JSONStyle string
+
+const (
+	JSONFlat   JSONStyle = "flat"   // плоский массив записей с path и depth
+	JSONNested JSONStyle = "nested" // документ с вложенными children, см. json_nested.go
+)
+
+// ParseJSONStyle проверяет имя стиля. Пустая строка означает JSONFlat.
+func ParseJSONStyle(s string) (JSONStyle, error) {
+	switch JSONStyle(strings.ToLower(s)) {
+	case "", JSONFlat:
+		return JSONFlat, nil
+	case JSONNested:
+		return JSONNested, nil
+	default:
+		return "", fmt.Errorf("unknown JSON style %q (supported: %s, %s)", s, JSONFlat, JSONNested)
+	}
+}
+
+type JSONExporter struct {
+	style JSONStyle
+	scan  *config.Config // параметры обхода для options в стиле nested, может быть nil
+}
+
+// NewJSONExporter читает стиль ("json_style") и параметры обхода
+// ("scan_config") из конфигурации экспорта
+func NewJSONExporter(cfg map[string]interface{}) (Exporter, error) {
+	name, _ := cfg["json_style"].(string)
+	style, err := ParseJSONStyle(name)
+	if err != nil {
+		return nil, err
+	}
+	scan, _ := cfg["scan_config"].(*config.Config)
+	return &JSONExporter{style: style, scan: scan}, nil
+}
 
 // JSONEntry структура для сериализации
 type JSONEntry struct {
@@ -20,23 +60,126 @@ type JSONEntry struct {
 	Depth    int       `json:"depth"`
 	ModTime  time.Time `json:"mod_time"`
 	IsHidden bool      `json:"is_hidden"`
-}
 
-func (e *JSONExporter) Export(w io.Writer, entries []types.Entry) error {
-	jsonEntries := make([]JSONEntry, len(entries))
+	LinkTarget   string `json:"link_target,omitempty"`
+	Broken       bool   `json:"broken,omitempty"`
+	Archive      bool   `json:"archive,omitempty"`
+	Mount        string `json:"mount,omitempty"`
+	MountSkipped bool   `json:"mount_skipped,omitempty"`
+	OverLimit    int    `json:"over_limit,omitempty"`   // записей в директории, пропущенной --filelimit
+	Omitted      int    `json:"omitted,omitempty"`      // у записи типа "omitted": сколько соседей скрыто --max-children
+	Links        int    `json:"links,omitempty"`        // число жёстких ссылок, если их больше одной
+	HardLinkDup  bool   `json:"hardlink_dup,omitempty"` // размер уже учтён у другой ссылки
+	Hash         string `json:"hash,omitempty"`         // "sha256:hex" при --hash и в gotree dupes
+	Kind         string `json:"kind,omitempty"`         // вид файла: image, source, executable…
+	MIME         string `json:"mime,omitempty"`
+	Lang         string `json:"lang,omitempty"`
+	Git          string `json:"git,omitempty"` // состояние в git при --git-status, например "-M"
+
+	// История git: последний коммит, его автор и число коммитов
+	LastCommit *time.Time `json:"last_commit,omitempty"`
+	LastAuthor string     `json:"last_author,omitempty"`
+	Commits    int        `json:"commits,omitempty"`
+	Error      string     `json:"error,omitempty"`
+
+	// Только для директорий: итоги по поддереву
+	TotalSize *int64 `json:"total_size,omitempty"`
+	FileCount *int   `json:"file_count,omitempty"`
+
+	// Строки кода при --loc: у файла свои, у директории сумма по поддереву.
+	// Корень дополнительно получает итоги по языкам из метрик.
+	Loc       *loc.Counts        `json:"loc,omitempty"`
+	Languages []metrics.Language `json:"languages,omitempty"`
+}
 
-	for i, entry := range entries {
-		jsonEntries[i] = JSONEntry{
-			Path:     entry.Path,
-			Type:     map[bool]string{true: "directory", false: "file"}[entry.Info.IsDir()],
-			Size:     entry.Info.Size(),
-			Depth:    entry.Depth,
-			ModTime:  entry.Info.ModTime(),
-			IsHidden: strings.HasPrefix(filepath.Base(entry.Path), "."),
+func (e *JSONExporter) Export(w io.Writer, result tree.WalkResult) error {
+	var doc any
+	if e.style == JSONNested {
+		doc = newJSONDocument(result, e.scan)
+	} else {
+		jsonEntries := []JSONEntry{}
+		if result.Root != nil {
+			_ = result.Root.Walk(func(node *tree.Node) error {
+				entry := newJSONEntry(node)
+				if node.IsRoot() {
+					entry.Languages = result.Metrics.Languages
+				}
+				jsonEntries = append(jsonEntries, entry)
+				return nil
+			})
 		}
+		doc = jsonEntries
 	}
 
 	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
-	return encoder.Encode(jsonEntries)
+	return encoder.Encode(doc)
+}
+
+// newJSONEntry переводит узел в запись JSON, общую для обоих стилей
+func newJSONEntry(node *tree.Node) JSONEntry {
+	entry := JSONEntry{
+		Path:     node.Path,
+		Type:     entryType(node),
+		Size:     node.Info.Size(),
+		Depth:    node.Depth,
+		ModTime:  node.Info.ModTime(),
+		IsHidden: strings.HasPrefix(filepath.Base(node.Path), "."),
+
+		LinkTarget:   node.LinkTarget,
+		Broken:       node.Broken,
+		Archive:      node.Archive,
+		Mount:        node.Mount,
+		MountSkipped: node.MountSkipped,
+		OverLimit:    node.OverLimit,
+		Omitted:      node.Omitted,
+		HardLinkDup:  node.HardLinkDup,
+		Hash:         node.Hash,
+		Kind:         node.Kind,
+		MIME:         node.MIME,
+		Lang:         node.Lang,
+		Git:          node.Git,
+		Error:        errorString(node.Err),
+	}
+	if node.Commits > 0 {
+		entry.LastCommit = &node.LastCommit
+		entry.LastAuthor = node.LastAuthor
+		entry.Commits = node.Commits
+	}
+	if node.Links > 1 {
+		entry.Links = node.Links
+	}
+	if node.IsDir() {
+		entry.TotalSize = &node.Size
+		entry.FileCount = &node.FileCount
+	}
+	if node.Archive || node.Omitted > 0 {
+		entry.FileCount = &node.FileCount
+	}
+	if node.Loc.Files > 0 {
+		entry.Loc = &node.Loc
+	}
+	return entry
+}
+
+// entryType возвращает "directory", "file", "symlink" для нераскрытых ссылок
+// или "omitted" для сводки --max-children
+func entryType(node *tree.Node) string {
+	switch {
+	case node.Omitted > 0:
+		return "omitted"
+	case node.IsDir():
+		return "directory"
+	case node.Info.Mode()&os.ModeSymlink != 0:
+		return "symlink"
+	default:
+		return "file"
+	}
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
 }
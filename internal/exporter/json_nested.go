@@ -0,0 +1,142 @@
+package exporter
+
+import (
+	"time"
+
+	"github.com/massonsky/gotree/internal/config"
+	"github.com/massonsky/gotree/internal/metrics"
+	"github.com/massonsky/gotree/internal/tree"
+)
+
+// JSONSchemaVersion версия документа --json-style nested. Схема лежит
+// в assets/schema и выводится командой gotree schema. Новые необязательные
+// поля меняют младшую цифру, несовместимые изменения — старшую.
+const JSONSchemaVersion = "1.0"
+
+// JSONDocument документ стиля nested: дерево и всё, что известно об обходе
+type JSONDocument struct {
+	SchemaVersion string          `json:"schema_version"`
+	Root          *JSONNode       `json:"root"` // nil, если дерева нет
+	GeneratedAt   time.Time       `json:"generated_at"`
+	Options       JSONOptions     `json:"options"`
+	Metrics       metrics.Metrics `json:"metrics"`
+	Errors        []JSONError     `json:"errors"`
+}
+
+// JSONNode запись с вложенными детьми. Поля те же, что в плоском стиле,
+// плюс имя; children есть у директорий и раскрытых архивов, даже пустых.
+type JSONNode struct {
+	Name string `json:"name"`
+	JSONEntry
+	Children *[]JSONNode `json:"children,omitempty"`
+}
+
+// JSONOptions параметры обхода, от которых зависит содержимое дерева
+type JSONOptions struct {
+	MaxDepth        int      `json:"max_depth"`
+	ShowHidden      bool     `json:"show_hidden"`
+	IgnorePatterns  []string `json:"ignore_patterns,omitempty"`
+	GitIgnore       bool     `json:"gitignore"`
+	Where           string   `json:"where,omitempty"`
+	IncludePatterns []string `json:"include_patterns,omitempty"`
+	IncludeRegex    []string `json:"include_regex,omitempty"`
+	MatchDirs       bool     `json:"match_dirs"`
+	Prune           bool     `json:"prune"`
+	FollowSymlinks  bool     `json:"follow_symlinks"`
+	OneFileSystem   bool     `json:"one_file_system"`
+	IntoArchives    bool     `json:"into_archives"`
+	FileLimit       int      `json:"filelimit,omitempty"`
+	MaxChildren     int      `json:"max_children,omitempty"`
+	SortBy          string   `json:"sort_by"`
+	DirsFirst       bool     `json:"dirs_first"`
+	SortReverse     bool     `json:"sort_reverse"`
+	SizeMode        string   `json:"size_mode"`
+	SniffContent    bool     `json:"sniff_content"`
+	Hash            string   `json:"hash,omitempty"` // алгоритм при --hash
+	Loc             bool     `json:"loc"`
+	GitStatus       bool     `json:"git_status"`
+	GitChangedOnly  bool     `json:"git_changed_only"`
+	GitHistory      bool     `json:"git_history"`
+}
+
+// JSONError путь, который не удалось прочитать
+type JSONError struct {
+	Path  string `json:"path"`
+	Op    string `json:"op"` // readdir, lstat, readlink, archive, hash, loc
+	Error string `json:"error"`
+}
+
+// newJSONDocument собирает документ стиля nested
+func newJSONDocument(result tree.WalkResult, scan *config.Config) JSONDocument {
+	doc := JSONDocument{
+		SchemaVersion: JSONSchemaVersion,
+		GeneratedAt:   time.Now().UTC().Truncate(time.Second),
+		Options:       newJSONOptions(scan),
+		Metrics:       result.Metrics,
+		Errors:        newJSONErrors(result.Errors),
+	}
+	if result.Root != nil {
+		root := newJSONNode(result.Root)
+		doc.Root = &root
+	}
+	return doc
+}
+
+// newJSONErrors переводит ошибки обхода в записи; пустой список — [], а не null
+func newJSONErrors(scanErrors []tree.ScanError) []JSONError {
+	errs := make([]JSONError, 0, len(scanErrors))
+	for _, scanErr := range scanErrors {
+		errs = append(errs, JSONError{Path: scanErr.Path, Op: scanErr.Op, Error: scanErr.Err.Error()})
+	}
+	return errs
+}
+
+// newJSONNode переводит поддерево в записи с вложенными детьми
+func newJSONNode(node *tree.Node) JSONNode {
+	n := JSONNode{Name: node.Name(), JSONEntry: newJSONEntry(node)}
+	if !node.IsDir() && !node.Archive {
+		return n
+	}
+	children := make([]JSONNode, 0, len(node.Children))
+	for _, child := range node.Children {
+		children = append(children, newJSONNode(child))
+	}
+	n.Children = &children
+	return n
+}
+
+// newJSONOptions выбирает из конфигурации параметры, влияющие на дерево
+func newJSONOptions(cfg *config.Config) JSONOptions {
+	if cfg == nil {
+		return JSONOptions{}
+	}
+	opts := JSONOptions{
+		MaxDepth:        cfg.MaxDepth,
+		ShowHidden:      cfg.ShowHiddenFiles,
+		IgnorePatterns:  cfg.IgnorePatterns,
+		GitIgnore:       cfg.GitIgnore,
+		Where:           cfg.Where,
+		IncludePatterns: cfg.IncludePatterns,
+		IncludeRegex:    cfg.IncludeRegex,
+		MatchDirs:       cfg.MatchDirs,
+		Prune:           cfg.Prune,
+		FollowSymlinks:  cfg.FollowSymlinks,
+		OneFileSystem:   cfg.OneFileSystem,
+		IntoArchives:    cfg.IntoArchives,
+		FileLimit:       cfg.FileLimit,
+		MaxChildren:     cfg.MaxChildren,
+		SortBy:          cfg.SortBy,
+		DirsFirst:       cfg.DirsFirst,
+		SortReverse:     cfg.SortReverse,
+		SizeMode:        cfg.SizeMode,
+		SniffContent:    cfg.SniffContent,
+		Loc:             cfg.Loc,
+		GitStatus:       cfg.GitStatus,
+		GitChangedOnly:  cfg.GitChangedOnly,
+		GitHistory:      cfg.GitHistory,
+	}
+	if cfg.Hash {
+		opts.Hash = cfg.HashAlgo
+	}
+	return opts
+}
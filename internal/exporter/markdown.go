@@ -0,0 +1,188 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/massonsky/gotree/internal/tree"
+)
+
+// MarkdownStyle вид дерева в Markdown
+type MarkdownStyle string
+
+const (
+	MarkdownCode MarkdownStyle = "code" // блок кода с псевдографикой, как в TXT
+	MarkdownList MarkdownStyle = "list" // вложенный список со ссылками на файлы
+)
+
+// ParseMarkdownStyle проверяет имя стиля. Пустая строка означает MarkdownCode.
+func ParseMarkdownStyle(s string) (MarkdownStyle, error) {
+	switch MarkdownStyle(strings.ToLower(s)) {
+	case "", MarkdownCode:
+		return MarkdownCode, nil
+	case MarkdownList:
+		return MarkdownList, nil
+	default:
+		return "", fmt.Errorf("unknown markdown style %q (supported: %s, %s)", s, MarkdownCode, MarkdownList)
+	}
+}
+
+// MarkdownExporter пишет дерево для README и документации: блоком кода
+// или вложенным списком со ссылками, по желанию — с таблицей метрик.
+// Строки те же, что у TextExporter.
+type MarkdownExporter struct {
+	opts    Options
+	style   MarkdownStyle
+	metrics bool
+}
+
+// NewMarkdownExporter читает стиль ("markdown_style") и таблицу метрик
+// ("markdown_metrics") из конфигурации экспорта
+func NewMarkdownExporter(cfg map[string]interface{}) (Exporter, error) {
+	name, _ := cfg["markdown_style"].(string)
+	style, err := ParseMarkdownStyle(name)
+	if err != nil {
+		return nil, err
+	}
+	withMetrics, _ := cfg["markdown_metrics"].(bool)
+	return &MarkdownExporter{opts: optionsFromConfig(cfg), style: style, metrics: withMetrics}, nil
+}
+
+func (e *MarkdownExporter) Export(w io.Writer, result tree.WalkResult) error {
+	if result.Root == nil {
+		return nil
+	}
+
+	bw := bufio.NewWriter(w)
+	if e.style == MarkdownList {
+		e.writeList(bw, result.Root)
+	} else {
+		e.writeCode(bw, result.Root)
+	}
+	if e.metrics {
+		writeMarkdownMetrics(bw, result)
+	}
+	return bw.Flush()
+}
+
+// writeCode пишет дерево блоком кода. Ограда длиннее любой серии
+// обратных кавычек в именах, чтобы блок не закрылся раньше времени.
+func (e *MarkdownExporter) writeCode(w io.Writer, root *tree.Node) {
+	var lines []string
+	fence := "```"
+	_ = root.Walk(func(node *tree.Node) error {
+		line := formatTextEntry(node, e.opts)
+		for strings.Contains(line, fence) {
+			fence += "`"
+		}
+		lines = append(lines, line)
+		return nil
+	})
+
+	fmt.Fprintln(w, fence+"text")
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w, fence)
+}
+
+// writeList пишет дерево вложенным списком. Ссылки ведут на пути
+// относительно корня дерева, так что файл стоит класть в корень;
+// записи внутри архивов и сводки --max-children идут без ссылок.
+func (e *MarkdownExporter) writeList(w io.Writer, root *tree.Node) {
+	_ = root.Walk(func(node *tree.Node) error {
+		name := markdownEscape(node.Name())
+		if node.IsDir() {
+			name += "/"
+		}
+
+		if node.IsRoot() {
+			fmt.Fprintf(w, "%s**%s**%s\n\n", markdownGit(node, e.opts), name, sizeLabel(node, e.opts))
+			return nil
+		}
+
+		indent := strings.Repeat("  ", node.Depth-1)
+		switch {
+		case node.Omitted > 0:
+			fmt.Fprintf(w, "%s- *%s*\n", indent, name)
+			return nil
+		case !inArchive(node):
+			name = fmt.Sprintf("[%s](%s)", name, markdownLink(node))
+		}
+
+		line := fmt.Sprintf("%s- %s%s %s%s%s", indent, markdownGit(node, e.opts), entryIcon(node), name,
+			markdownEscape(node.Suffix()), sizeLabel(node, e.opts))
+		if e.opts.GitHistory {
+			line += node.HistoryLabel()
+		}
+		fmt.Fprintln(w, line+node.LocLabel())
+		return nil
+	})
+}
+
+// markdownGit колонка git в виде кода, чтобы "--" не стало тире
+func markdownGit(node *tree.Node, opts Options) string {
+	label := gitLabel(node, opts)
+	if strings.TrimSpace(label) == "" {
+		return ""
+	}
+	return "`" + strings.TrimSpace(label) + "` "
+}
+
+// markdownLink относительная ссылка на запись: каждый сегмент пути
+// экранируется отдельно, у директорий — слеш в конце
+func markdownLink(node *tree.Node) string {
+	parts := strings.Split(strings.ReplaceAll(node.Path, "\\", "/"), "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	link := strings.Join(parts, "/")
+	if node.IsDir() {
+		link += "/"
+	}
+	return link
+}
+
+// inArchive сообщает, лежит ли запись внутри раскрытого архива
+func inArchive(node *tree.Node) bool {
+	for p := node.Parent; p != nil; p = p.Parent {
+		if p.Archive {
+			return true
+		}
+	}
+	return false
+}
+
+// markdownEscaper экранирует символы разметки в именах файлов
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`,
+)
+
+func markdownEscape(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// writeMarkdownMetrics пишет таблицу метрик и, если считались строки кода,
+// таблицу языков
+func writeMarkdownMetrics(w io.Writer, result tree.WalkResult) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Metric | Value |")
+	fmt.Fprintln(w, "|--------|-------|")
+	for _, row := range metricRows(result.Metrics) {
+		fmt.Fprintf(w, "| %s | %s |\n", row.Label, row.Value)
+	}
+
+	if len(result.Metrics.Languages) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Language | Files | Code | Comment | Blank |")
+	fmt.Fprintln(w, "|----------|------:|-----:|--------:|------:|")
+	for _, l := range result.Metrics.Languages {
+		fmt.Fprintf(w, "| %s | %d | %d | %d | %d |\n", l.Lang, l.Files, l.Code, l.Comment, l.Blank)
+	}
+}
@@ -0,0 +1,60 @@
+package exporter
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/massonsky/gotree/internal/metrics"
+	"github.com/massonsky/gotree/internal/tree"
+)
+
+// NDJSONExporter пишет по одной компактной записи JSON на строку — те же
+// поля, что у плоского JSON, — и в конце строку-итог с метриками. Обычно
+// записи уходят по мере обхода (ExportStream), так что вывод можно сразу
+// передавать в jq; Export пишет готовое дерево, когда опциям вроде --du
+// или --hash нужно всё дерево, а также для списка путей и dupes.
+type NDJSONExporter struct{}
+
+// NDJSONSummary последняя строка вывода; тип "summary" отличает её от записей
+type NDJSONSummary struct {
+	Type    string          `json:"type"`
+	Metrics metrics.Metrics `json:"metrics"`
+	Errors  []JSONError     `json:"errors"`
+}
+
+func (e *NDJSONExporter) Export(w io.Writer, result tree.WalkResult) error {
+	encoder := json.NewEncoder(w)
+	if result.Root != nil {
+		err := result.Root.Walk(func(node *tree.Node) error {
+			return encoder.Encode(newJSONEntry(node))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return encoder.Encode(newNDJSONSummary(result.Metrics, result.Errors))
+}
+
+// ExportStream пишет записи по мере обхода. Итоги директорий в потоке
+// неизвестны, поэтому total_size и file_count у них не пишутся.
+func (e *NDJSONExporter) ExportStream(w io.Writer, stream *tree.Stream) error {
+	encoder := json.NewEncoder(w)
+	for node, err := range stream.Nodes() {
+		if err != nil {
+			return err
+		}
+		entry := newJSONEntry(node)
+		if node.IsDir() {
+			entry.TotalSize, entry.FileCount = nil, nil
+		}
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return encoder.Encode(newNDJSONSummary(stream.Metrics(), stream.Errors()))
+}
+
+// newNDJSONSummary собирает строку-итог
+func newNDJSONSummary(m metrics.Metrics, scanErrors []tree.ScanError) NDJSONSummary {
+	return NDJSONSummary{Type: "summary", Metrics: m, Errors: newJSONErrors(scanErrors)}
+}
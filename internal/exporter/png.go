@@ -3,10 +3,9 @@ package exporter
 import (
 	"fmt"
 	"io"
-	"path/filepath"
 
 	"github.com/massonsky/gotree/assets"
-	"github.com/massonsky/gotree/internal/types"
+	"github.com/massonsky/gotree/internal/tree"
 
 	"github.com/fogleman/gg"
 	"github.com/golang/freetype/truetype"
@@ -14,6 +13,7 @@ import (
 
 type PNGExporter struct {
 	fontPath string
+	opts     Options
 }
 
 func NewPNGExporter(cfg map[string]interface{}) (Exporter, error) {
@@ -21,15 +21,15 @@ func NewPNGExporter(cfg map[string]interface{}) (Exporter, error) {
 	if !ok {
 		fontPath = ""
 	}
-	return &PNGExporter{fontPath: fontPath}, nil
+	return &PNGExporter{fontPath: fontPath, opts: optionsFromConfig(cfg)}, nil
 }
 
-func (e *PNGExporter) Export(w io.Writer, entries []types.Entry) error {
-	if len(entries) == 0 {
+func (e *PNGExporter) Export(w io.Writer, result tree.WalkResult) error {
+	if result.Root == nil {
 		return fmt.Errorf("no entries to export")
 	}
 
-	height := calculateImageHeight(entries)
+	height := calculateImageHeight(result.Root)
 	dc := gg.NewContext(imageWidth, height)
 
 	// Фон
@@ -50,75 +50,42 @@ func (e *PNGExporter) Export(w io.Writer, entries []types.Entry) error {
 		return fmt.Errorf("failed to load font: %w", err)
 	}
 
-	dc.SetRGB(0.1, 0.1, 0.1)
 	y := padding + fontSize
 
-	// Рисуем корень
-	root := entries[0]
-	rootName := root.Path
-	if root.Info.IsDir() {
-		rootName += "/"
-	}
-	dc.DrawString(rootName, padding, float64(y))
-	y += lineHeight
-
-	// Создаём массив для отслеживания, где нужны вертикальные линии
-	// Для каждого уровня глубины: true = нужна вертикальная линия
-	needVerticalLine := make([]bool, 20) // максимум 20 уровней
-
-	for i := 1; i < len(entries); i++ {
-		entry := entries[i]
-
-		// Определяем, является ли текущий элемент последним в своей директории
-		isLast := false
-		if i+1 < len(entries) {
-			nextEntry := entries[i+1]
-			if nextEntry.Depth <= entry.Depth {
-				isLast = true
-			}
-		} else {
-			isLast = true
-		}
-
-		// Обновляем needVerticalLine для текущего уровня
-		if entry.Depth > 0 {
-			// Если текущий элемент НЕ последний — на этом уровне нужна вертикальная линия
-			needVerticalLine[entry.Depth] = !isLast
-		}
-
-		// Формируем префикс
-		prefix := ""
-		for d := 1; d < entry.Depth; d++ {
-			if needVerticalLine[d] {
-				prefix += "|   "
-			} else {
-				prefix += "-    "
-			}
+	_ = result.Root.Walk(func(node *tree.Node) error {
+		// Шрифт может не содержать псевдографику — рисуем ASCII
+		name := node.Name()
+		if node.IsDir() {
+			name += "/"
 		}
 
-		if entry.Depth > 0 {
-			if isLast {
-				prefix += "`--"
-			} else {
-				prefix += "+-- "
-			}
+		// Ссылки — пурпурным, битые ссылки — красным
+		switch {
+		case node.Omitted > 0:
+			dc.SetRGB(0.62, 0.62, 0.62)
+		case node.Broken:
+			dc.SetRGB(0.9, 0.22, 0.21)
+		case node.IsSymlink():
+			dc.SetRGB(0.56, 0.14, 0.67)
+		default:
+			dc.SetRGB(0.1, 0.1, 0.1)
 		}
 
-		name := filepath.Base(entry.Path)
-		if entry.Info.IsDir() {
-			name += "/"
+		line := gitLabel(node, e.opts) + node.Prefix(tree.ASCIIPrefixStyle) + name + node.Suffix()
+		if node.IsDir() {
+			line += sizeLabel(node, e.opts)
 		}
 
-		line := prefix + name
 		dc.DrawString(line, padding, float64(y))
 		y += lineHeight
-	}
+		return nil
+	})
 
 	return dc.EncodePNG(w)
 }
 
-func calculateImageHeight(entries []types.Entry) int {
-	return padding*2 + (len(entries) * lineHeight)
+func calculateImageHeight(root *tree.Node) int {
+	return padding*2 + (countNodes(root) * lineHeight)
 }
 
 // loadFontFromBytes загружает шрифт из []byte и устанавливает его в gg.Context
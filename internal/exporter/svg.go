@@ -3,22 +3,22 @@ package exporter
 import (
 	"fmt"
 	"io"
-	"path/filepath"
-	"strings"
 
-	"github.com/massonsky/gotree/internal/types"
+	"github.com/massonsky/gotree/internal/tree"
 
 	svg "github.com/ajstarks/svgo"
 )
 
-type SVGExporter struct{}
+type SVGExporter struct {
+	opts Options
+}
 
-func (e *SVGExporter) Export(w io.Writer, entries []types.Entry) error {
-	if len(entries) == 0 {
+func (e *SVGExporter) Export(w io.Writer, result tree.WalkResult) error {
+	if result.Root == nil {
 		return fmt.Errorf("no entries to export")
 	}
 
-	height := calculateSVGHeight(entries)
+	height := calculateSVGHeight(result.Root)
 	canvas := svg.New(w)
 	canvas.Start(svgWidth, height)
 
@@ -29,51 +29,42 @@ func (e *SVGExporter) Export(w io.Writer, entries []types.Entry) error {
 	fontStyle := fmt.Sprintf("font-family:monospace;font-size:%dpx", fontSize)
 
 	y := padding + fontSize
-	for i, entry := range entries {
-		isLast := (i == len(entries)-1)
-		prefix := buildTreePrefix(entry.Depth, isLast)
-
-		name := filepath.Base(entry.Path)
-		if entry.Info.IsDir() {
+	_ = result.Root.Walk(func(node *tree.Node) error {
+		name := node.Name()
+		if node.IsDir() {
 			name += "/"
 		}
 
-		line := prefix + name
+		line := gitLabel(node, e.opts) + node.Prefix(tree.BoxPrefixStyle) + name + node.Suffix()
+		if node.IsDir() {
+			line += sizeLabel(node, e.opts)
+		}
 
-		// Цвет текста: синий для директорий, чёрный для файлов
+		// Цвет текста: синий для директорий, чёрный для файлов,
+		// пурпурный для ссылок, красный зачёркнутый для битых ссылок
 		color := "#000000"
-		if entry.Info.IsDir() {
+		decoration := ""
+		switch {
+		case node.Omitted > 0:
+			color = "#9e9e9e"
+		case node.Broken:
+			color = "#e53935"
+			decoration = ";text-decoration:line-through"
+		case node.IsSymlink():
+			color = "#8e24aa"
+		case node.IsDir():
 			color = "#1e88e5"
 		}
 
-		canvas.Text(padding, y, line, fontStyle+" fill:"+color)
+		canvas.Text(padding, y, line, fontStyle+";fill:"+color+decoration)
 		y += lineHeight
-	}
+		return nil
+	})
 
 	canvas.End()
 	return nil
 }
 
-func calculateSVGHeight(entries []types.Entry) int {
-	return padding*2 + (len(entries) * lineHeight)
-}
-
-// buildTreePrefix формирует префикс для дерева (как в console.go)
-func buildTreePrefix(depth int, isLast bool) string {
-	if depth <= 0 {
-		return ""
-	}
-
-	parts := make([]string, depth)
-	for d := 1; d < depth; d++ {
-		parts[d-1] = "│   "
-	}
-
-	if isLast {
-		parts[depth-1] = "└── "
-	} else {
-		parts[depth-1] = "├── "
-	}
-
-	return strings.Join(parts, "")
+func calculateSVGHeight(root *tree.Node) int {
+	return padding*2 + (countNodes(root) * lineHeight)
 }
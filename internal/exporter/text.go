@@ -3,68 +3,55 @@ package exporter
 import (
 	"fmt"
 	"io"
-	"path/filepath"
 
-	_types "github.com/massonsky/gotree/internal/types"
+	"github.com/massonsky/gotree/internal/tree"
 )
 
-type TextExporter struct{}
+type TextExporter struct {
+	opts Options
+}
 
-func (e *TextExporter) Export(w io.Writer, entries []_types.Entry) error {
-	if len(entries) == 0 {
+func (e *TextExporter) Export(w io.Writer, result tree.WalkResult) error {
+	if result.Root == nil {
 		return nil
 	}
 
-	// Находим максимальную глубину
-	maxDepth := 0
-	for _, entry := range entries {
-		if entry.Depth > maxDepth {
-			maxDepth = entry.Depth
-		}
-	}
-
 	// Генерируем строки
-	for i, entry := range entries {
-		isLast := (i == len(entries)-1)
-		line := formatTextEntry(entry, isLast, maxDepth)
-		if _, err := w.Write([]byte(line + "\n")); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return result.Root.Walk(func(node *tree.Node) error {
+		line := formatTextEntry(node, e.opts)
+		_, err := w.Write([]byte(line + "\n"))
+		return err
+	})
 }
 
-func formatTextEntry(entry _types.Entry, isLast bool, maxDepth int) string {
-	prefix := ""
-	if entry.Depth > 0 {
-		for d := 1; d < entry.Depth; d++ {
-			prefix += "│   "
+// ExportStream пишет строки по мере обхода. Размеры директорий в потоке
+// неизвестны, поэтому режим du здесь не действует.
+func (e *TextExporter) ExportStream(w io.Writer, stream *tree.Stream) error {
+	opts := e.opts
+	opts.DiskUsage = false
+
+	for node, err := range stream.Nodes() {
+		if err != nil {
+			return err
 		}
-		if isLast {
-			prefix += "└── "
-		} else {
-			prefix += "├── "
+		if _, err := w.Write([]byte(formatTextEntry(node, opts) + "\n")); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	icon := "📄"
-	if entry.Info.IsDir() {
-		icon = "📁"
-	}
-
-	name := filepath.Base(entry.Path)
-	if entry.Depth == 0 {
-		name = entry.Path
+func formatTextEntry(node *tree.Node, opts Options) string {
+	prefix := node.Prefix(tree.BoxPrefixStyle)
+	if node.Omitted > 0 {
+		return gitLabel(node, opts) + prefix + node.Name()
 	}
 
-	line := fmt.Sprintf("%s%s %s", prefix, icon, name)
-
-	if !entry.Info.IsDir() {
-		size := formatSize(entry.Info.Size())
-		line += fmt.Sprintf(" (%s)", size)
+	line := fmt.Sprintf("%s%s%s %s%s%s", gitLabel(node, opts), prefix, entryIcon(node), node.Name(), node.Suffix(), sizeLabel(node, opts))
+	if opts.GitHistory {
+		line += node.HistoryLabel()
 	}
-
+	line += node.LocLabel()
 	return line
 }
 
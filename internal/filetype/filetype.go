@@ -0,0 +1,145 @@
+// Package filetype определяет вид файла по имени и по первым байтам
+// содержимого: изображение, архив, исполняемый файл, скрипт с shebang,
+// исходный код, текст или двоичные данные.
+//
+// По одному имени вид известен не всегда; содержимое уточняет его
+// и перекрывает расширение, если они расходятся (PNG с расширением .txt
+// — всё равно изображение).
+package filetype
+
+import (
+	"bytes"
+	"io/fs"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// Kind вид файла
+type Kind string
+
+const (
+	Unknown    Kind = ""
+	Text       Kind = "text"
+	Source     Kind = "source"
+	Script     Kind = "script"
+	Image      Kind = "image"
+	Audio      Kind = "audio"
+	Video      Kind = "video"
+	Archive    Kind = "archive"
+	Document   Kind = "document"
+	Font       Kind = "font"
+	Executable Kind = "executable"
+	Binary     Kind = "binary"
+)
+
+// Kinds все известные виды в порядке для справки
+var Kinds = []Kind{Text, Source, Script, Image, Audio, Video, Archive, Document, Font, Executable, Binary}
+
+// SniffLen сколько байт с начала файла нужно Detect
+const SniffLen = 512
+
+// Type результат определения
+type Type struct {
+	Kind Kind
+	MIME string // "" — неизвестен
+	Lang string // язык или формат для исходников, скриптов и текстовых форматов: "go", "python", "yaml"
+}
+
+// Detect определяет тип обычного файла по имени, правам и началу
+// содержимого. head == nil — содержимое не читалось, тогда тип берётся
+// только из имени и бита исполнения.
+func Detect(name string, mode fs.FileMode, head []byte) Type {
+	t := byName(name)
+	if head == nil {
+		if t.Kind == Unknown && mode&0o111 != 0 {
+			t = Type{Kind: Executable}
+		}
+		return t
+	}
+
+	if m, ok := sniff(head); ok {
+		// docx, jar и прочие форматы поверх zip точнее определяет расширение
+		if m.MIME == "application/zip" && (t.Kind == Document || t.Kind == Archive) {
+			return t
+		}
+		return m
+	}
+	if t.Kind != Unknown {
+		return t
+	}
+	if isText(head) {
+		return Type{Kind: Text, MIME: "text/plain"}
+	}
+	return Type{Kind: Binary, MIME: "application/octet-stream"}
+}
+
+// byName определяет тип по имени файла
+func byName(name string) Type {
+	base := path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if t, ok := byFileName[base]; ok {
+		return t
+	}
+	lower := strings.ToLower(base)
+	if strings.HasSuffix(lower, ".tar.gz") {
+		return byExtension[".tgz"]
+	}
+	return byExtension[path.Ext(lower)]
+}
+
+// sniff ищет сигнатуру формата в начале содержимого
+func sniff(head []byte) (Type, bool) {
+	for _, m := range magics {
+		if len(head) >= m.offset+len(m.prefix) && bytes.Equal(head[m.offset:m.offset+len(m.prefix)], m.prefix) {
+			return m.typ, true
+		}
+	}
+	if bytes.HasPrefix(head, []byte("#!")) {
+		return Type{Kind: Script, MIME: "text/x-script", Lang: interpreter(head)}, true
+	}
+	return Type{}, false
+}
+
+// interpreter возвращает язык скрипта по строке shebang:
+// "#!/usr/bin/env python3" → "python"
+func interpreter(head []byte) string {
+	line, _, _ := bytes.Cut(head[2:], []byte("\n"))
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return ""
+	}
+	prog := path.Base(fields[0])
+	if prog == "env" {
+		// #!/usr/bin/env -S node --flags
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") {
+				prog = path.Base(f)
+				break
+			}
+		}
+	}
+	prog = strings.TrimRight(prog, "0123456789.")
+	if lang, ok := interpreters[prog]; ok {
+		return lang
+	}
+	return prog
+}
+
+// isText сообщает, похоже ли содержимое на текст: нет нулевых байтов
+// и это UTF-8 (последний символ мог обрезаться на границе буфера)
+func isText(head []byte) bool {
+	if bytes.HasPrefix(head, []byte{0xFF, 0xFE}) || bytes.HasPrefix(head, []byte{0xFE, 0xFF}) {
+		return true // UTF-16 с BOM
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return false
+	}
+	for len(head) > 0 {
+		r, size := utf8.DecodeRune(head)
+		if r == utf8.RuneError && size == 1 {
+			return len(head) < utf8.UTFMax && !utf8.FullRune(head)
+		}
+		head = head[size:]
+	}
+	return true
+}
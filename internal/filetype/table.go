@@ -0,0 +1,229 @@
+package filetype
+
+// magic сигнатура формата: prefix по смещению offset
+type magic struct {
+	offset int
+	prefix []byte
+	typ    Type
+}
+
+var magics = []magic{
+	// Исполняемые файлы
+	{0, []byte("\x7fELF"), Type{Kind: Executable, MIME: "application/x-elf"}},
+	{0, []byte("MZ\x90\x00"), Type{Kind: Executable, MIME: "application/vnd.microsoft.portable-executable"}},
+	{0, []byte{0xFE, 0xED, 0xFA, 0xCE}, Type{Kind: Executable, MIME: "application/x-mach-binary"}},
+	{0, []byte{0xFE, 0xED, 0xFA, 0xCF}, Type{Kind: Executable, MIME: "application/x-mach-binary"}},
+	{0, []byte{0xCE, 0xFA, 0xED, 0xFE}, Type{Kind: Executable, MIME: "application/x-mach-binary"}},
+	{0, []byte{0xCF, 0xFA, 0xED, 0xFE}, Type{Kind: Executable, MIME: "application/x-mach-binary"}},
+
+	// Изображения
+	{0, []byte("\x89PNG\r\n\x1a\n"), Type{Kind: Image, MIME: "image/png"}},
+	{0, []byte{0xFF, 0xD8, 0xFF}, Type{Kind: Image, MIME: "image/jpeg"}},
+	{0, []byte("GIF87a"), Type{Kind: Image, MIME: "image/gif"}},
+	{0, []byte("GIF89a"), Type{Kind: Image, MIME: "image/gif"}},
+	{8, []byte("WEBP"), Type{Kind: Image, MIME: "image/webp"}},
+	{0, []byte{0x00, 0x00, 0x01, 0x00}, Type{Kind: Image, MIME: "image/x-icon"}},
+	{0, []byte("II*\x00"), Type{Kind: Image, MIME: "image/tiff"}},
+	{0, []byte("MM\x00*"), Type{Kind: Image, MIME: "image/tiff"}},
+
+	// Звук и видео
+	{8, []byte("WAVE"), Type{Kind: Audio, MIME: "audio/wav"}},
+	{8, []byte("AVI "), Type{Kind: Video, MIME: "video/x-msvideo"}},
+	{0, []byte("ID3"), Type{Kind: Audio, MIME: "audio/mpeg"}},
+	{0, []byte("fLaC"), Type{Kind: Audio, MIME: "audio/flac"}},
+	{0, []byte("OggS"), Type{Kind: Audio, MIME: "audio/ogg"}},
+	{4, []byte("ftyp"), Type{Kind: Video, MIME: "video/mp4"}},
+	{0, []byte{0x1A, 0x45, 0xDF, 0xA3}, Type{Kind: Video, MIME: "video/x-matroska"}},
+
+	// Документы
+	{0, []byte("%PDF-"), Type{Kind: Document, MIME: "application/pdf"}},
+
+	// Архивы и сжатые данные
+	{0, []byte("PK\x03\x04"), Type{Kind: Archive, MIME: "application/zip"}},
+	{0, []byte("PK\x05\x06"), Type{Kind: Archive, MIME: "application/zip"}},
+	{0, []byte{0x1F, 0x8B}, Type{Kind: Archive, MIME: "application/gzip"}},
+	{0, []byte("BZh"), Type{Kind: Archive, MIME: "application/x-bzip2"}},
+	{0, []byte{0xFD, '7', 'z', 'X', 'Z', 0x00}, Type{Kind: Archive, MIME: "application/x-xz"}},
+	{0, []byte{0x28, 0xB5, 0x2F, 0xFD}, Type{Kind: Archive, MIME: "application/zstd"}},
+	{0, []byte{'7', 'z', 0xBC, 0xAF, 0x27, 0x1C}, Type{Kind: Archive, MIME: "application/x-7z-compressed"}},
+	{0, []byte("Rar!\x1a\x07"), Type{Kind: Archive, MIME: "application/vnd.rar"}},
+	{257, []byte("ustar"), Type{Kind: Archive, MIME: "application/x-tar"}},
+
+	// Шрифты
+	{0, []byte("wOFF"), Type{Kind: Font, MIME: "font/woff"}},
+	{0, []byte("wOF2"), Type{Kind: Font, MIME: "font/woff2"}},
+	{0, []byte("OTTO"), Type{Kind: Font, MIME: "font/otf"}},
+	{0, []byte{0x00, 0x01, 0x00, 0x00, 0x00}, Type{Kind: Font, MIME: "font/ttf"}},
+
+	// Прочие двоичные форматы
+	{0, []byte("\x00asm"), Type{Kind: Binary, MIME: "application/wasm"}},
+	{0, []byte("SQLite format 3\x00"), Type{Kind: Binary, MIME: "application/vnd.sqlite3"}},
+}
+
+// byExtension типы по расширению в нижнем регистре
+var byExtension = map[string]Type{
+	// Исходный код
+	".go":    {Kind: Source, MIME: "text/x-go", Lang: "go"},
+	".py":    {Kind: Source, MIME: "text/x-python", Lang: "python"},
+	".js":    {Kind: Source, MIME: "text/javascript", Lang: "javascript"},
+	".mjs":   {Kind: Source, MIME: "text/javascript", Lang: "javascript"},
+	".jsx":   {Kind: Source, MIME: "text/javascript", Lang: "javascript"},
+	".ts":    {Kind: Source, MIME: "text/x-typescript", Lang: "typescript"},
+	".tsx":   {Kind: Source, MIME: "text/x-typescript", Lang: "typescript"},
+	".rs":    {Kind: Source, MIME: "text/x-rust", Lang: "rust"},
+	".c":     {Kind: Source, MIME: "text/x-c", Lang: "c"},
+	".h":     {Kind: Source, MIME: "text/x-c", Lang: "c"},
+	".cc":    {Kind: Source, MIME: "text/x-c++", Lang: "cpp"},
+	".cpp":   {Kind: Source, MIME: "text/x-c++", Lang: "cpp"},
+	".hpp":   {Kind: Source, MIME: "text/x-c++", Lang: "cpp"},
+	".java":  {Kind: Source, MIME: "text/x-java", Lang: "java"},
+	".kt":    {Kind: Source, MIME: "text/x-kotlin", Lang: "kotlin"},
+	".swift": {Kind: Source, MIME: "text/x-swift", Lang: "swift"},
+	".rb":    {Kind: Source, MIME: "text/x-ruby", Lang: "ruby"},
+	".php":   {Kind: Source, MIME: "text/x-php", Lang: "php"},
+	".cs":    {Kind: Source, MIME: "text/x-csharp", Lang: "csharp"},
+	".lua":   {Kind: Source, MIME: "text/x-lua", Lang: "lua"},
+	".sql":   {Kind: Source, MIME: "application/sql", Lang: "sql"},
+	".proto": {Kind: Source, MIME: "text/x-protobuf", Lang: "protobuf"},
+	".html":  {Kind: Source, MIME: "text/html", Lang: "html"},
+	".htm":   {Kind: Source, MIME: "text/html", Lang: "html"},
+	".css":   {Kind: Source, MIME: "text/css", Lang: "css"},
+	".scss":  {Kind: Source, MIME: "text/x-scss", Lang: "scss"},
+	".sh":    {Kind: Script, MIME: "text/x-shellscript", Lang: "shell"},
+	".bash":  {Kind: Script, MIME: "text/x-shellscript", Lang: "shell"},
+	".zsh":   {Kind: Script, MIME: "text/x-shellscript", Lang: "shell"},
+	".ps1":   {Kind: Script, MIME: "text/x-powershell", Lang: "powershell"},
+
+	// Текст и текстовые форматы данных
+	".txt":  {Kind: Text, MIME: "text/plain"},
+	".log":  {Kind: Text, MIME: "text/plain"},
+	".md":   {Kind: Text, MIME: "text/markdown", Lang: "markdown"},
+	".rst":  {Kind: Text, MIME: "text/x-rst", Lang: "rst"},
+	".json": {Kind: Text, MIME: "application/json", Lang: "json"},
+	".yaml": {Kind: Text, MIME: "application/yaml", Lang: "yaml"},
+	".yml":  {Kind: Text, MIME: "application/yaml", Lang: "yaml"},
+	".toml": {Kind: Text, MIME: "application/toml", Lang: "toml"},
+	".xml":  {Kind: Text, MIME: "application/xml", Lang: "xml"},
+	".csv":  {Kind: Text, MIME: "text/csv", Lang: "csv"},
+	".ini":  {Kind: Text, MIME: "text/plain", Lang: "ini"},
+	".svg":  {Kind: Image, MIME: "image/svg+xml"},
+
+	// Изображения
+	".png":  {Kind: Image, MIME: "image/png"},
+	".jpg":  {Kind: Image, MIME: "image/jpeg"},
+	".jpeg": {Kind: Image, MIME: "image/jpeg"},
+	".gif":  {Kind: Image, MIME: "image/gif"},
+	".webp": {Kind: Image, MIME: "image/webp"},
+	".bmp":  {Kind: Image, MIME: "image/bmp"},
+	".ico":  {Kind: Image, MIME: "image/x-icon"},
+	".tif":  {Kind: Image, MIME: "image/tiff"},
+	".tiff": {Kind: Image, MIME: "image/tiff"},
+
+	// Звук и видео
+	".mp3":  {Kind: Audio, MIME: "audio/mpeg"},
+	".wav":  {Kind: Audio, MIME: "audio/wav"},
+	".flac": {Kind: Audio, MIME: "audio/flac"},
+	".ogg":  {Kind: Audio, MIME: "audio/ogg"},
+	".mp4":  {Kind: Video, MIME: "video/mp4"},
+	".mov":  {Kind: Video, MIME: "video/quicktime"},
+	".mkv":  {Kind: Video, MIME: "video/x-matroska"},
+	".webm": {Kind: Video, MIME: "video/webm"},
+	".avi":  {Kind: Video, MIME: "video/x-msvideo"},
+
+	// Документы
+	".pdf":  {Kind: Document, MIME: "application/pdf"},
+	".docx": {Kind: Document, MIME: "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+	".xlsx": {Kind: Document, MIME: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+	".pptx": {Kind: Document, MIME: "application/vnd.openxmlformats-officedocument.presentationml.presentation"},
+	".odt":  {Kind: Document, MIME: "application/vnd.oasis.opendocument.text"},
+
+	// Архивы
+	".zip": {Kind: Archive, MIME: "application/zip"},
+	".jar": {Kind: Archive, MIME: "application/java-archive"},
+	".tar": {Kind: Archive, MIME: "application/x-tar"},
+	".tgz": {Kind: Archive, MIME: "application/gzip"},
+	".gz":  {Kind: Archive, MIME: "application/gzip"},
+	".bz2": {Kind: Archive, MIME: "application/x-bzip2"},
+	".xz":  {Kind: Archive, MIME: "application/x-xz"},
+	".zst": {Kind: Archive, MIME: "application/zstd"},
+	".7z":  {Kind: Archive, MIME: "application/x-7z-compressed"},
+	".rar": {Kind: Archive, MIME: "application/vnd.rar"},
+
+	// Шрифты
+	".ttf":   {Kind: Font, MIME: "font/ttf"},
+	".otf":   {Kind: Font, MIME: "font/otf"},
+	".woff":  {Kind: Font, MIME: "font/woff"},
+	".woff2": {Kind: Font, MIME: "font/woff2"},
+
+	// Исполняемые и прочие двоичные файлы
+	".exe":    {Kind: Executable, MIME: "application/vnd.microsoft.portable-executable"},
+	".dll":    {Kind: Executable, MIME: "application/vnd.microsoft.portable-executable"},
+	".so":     {Kind: Executable, MIME: "application/x-elf"},
+	".wasm":   {Kind: Binary, MIME: "application/wasm"},
+	".o":      {Kind: Binary, MIME: "application/x-object"},
+	".a":      {Kind: Binary, MIME: "application/x-archive"},
+	".class":  {Kind: Binary, MIME: "application/java-vm"},
+	".pyc":    {Kind: Binary, MIME: "application/x-python-code"},
+	".db":     {Kind: Binary, MIME: "application/vnd.sqlite3"},
+	".sqlite": {Kind: Binary, MIME: "application/vnd.sqlite3"},
+}
+
+// byFileName типы файлов, которые узнаются по имени целиком
+var byFileName = map[string]Type{
+	"Makefile":    {Kind: Source, MIME: "text/x-makefile", Lang: "make"},
+	"Dockerfile":  {Kind: Source, MIME: "text/x-dockerfile", Lang: "dockerfile"},
+	"Jenkinsfile": {Kind: Source, MIME: "text/x-groovy", Lang: "groovy"},
+	"go.mod":      {Kind: Text, MIME: "text/plain", Lang: "gomod"},
+	"go.sum":      {Kind: Text, MIME: "text/plain"},
+	"LICENSE":     {Kind: Text, MIME: "text/plain"},
+	"README":      {Kind: Text, MIME: "text/plain"},
+}
+
+// interpreters языки по имени интерпретатора в shebang
+var interpreters = map[string]string{
+	"sh":      "shell",
+	"bash":    "shell",
+	"zsh":     "shell",
+	"dash":    "shell",
+	"ksh":     "shell",
+	"fish":    "fish",
+	"python":  "python",
+	"node":    "javascript",
+	"deno":    "typescript",
+	"ruby":    "ruby",
+	"perl":    "perl",
+	"php":     "php",
+	"lua":     "lua",
+	"pwsh":    "powershell",
+	"awk":     "awk",
+	"gawk":    "awk",
+	"Rscript": "r",
+}
+
+// Icon возвращает значок для вида файла
+func Icon(kind Kind) string {
+	switch kind {
+	case Source:
+		return "📝"
+	case Script:
+		return "📜"
+	case Image:
+		return "🖼️"
+	case Audio:
+		return "🎵"
+	case Video:
+		return "🎬"
+	case Archive:
+		return "🗜️"
+	case Document:
+		return "📕"
+	case Font:
+		return "🔤"
+	case Executable:
+		return "⚙️"
+	case Binary:
+		return "🔩"
+	default:
+		return "📄"
+	}
+}
@@ -0,0 +1,24 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// SyntaxError ошибка разбора выражения с позицией, на которую
+// указывает стрелка под текстом выражения
+type SyntaxError struct {
+	Expr string // исходное выражение
+	Pos  int    // смещение в байтах
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	col := utf8.RuneCountInString(e.Expr[:e.Pos])
+	return fmt.Sprintf("%s (column %d)\n  %s\n  %s^", e.Msg, col+1, e.Expr, strings.Repeat(" ", col))
+}
+
+func errorAt(src string, pos int, format string, args ...any) error {
+	return &SyntaxError{Expr: src, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,116 @@
+package filter
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/massonsky/gotree/internal/filetype"
+	"github.com/massonsky/gotree/internal/types"
+)
+
+type fieldType int
+
+const (
+	typeString fieldType = iota
+	typeInt
+	typeTime
+	typeBool
+)
+
+// field поле записи, доступное в выражении. Заполнен только геттер,
+// соответствующий типу поля.
+type field struct {
+	name string
+	typ  fieldType
+	str  func(types.Entry) string
+	num  func(types.Entry) int64
+	tim  func(types.Entry) time.Time
+	bit  func(types.Entry) bool
+}
+
+var fields = map[string]field{
+	"name":   {typ: typeString, str: entryName},
+	"path":   {typ: typeString, str: func(e types.Entry) string { return filepath.ToSlash(e.Path) }},
+	"ext":    {typ: typeString, str: entryExt},
+	"type":   {typ: typeString, str: entryType},
+	"owner":  {typ: typeString, str: func(e types.Entry) string { return owner(e.Info) }},
+	"size":   {typ: typeInt, num: func(e types.Entry) int64 { return infoOr(e, 0, fs.FileInfo.Size) }},
+	"depth":  {typ: typeInt, num: func(e types.Entry) int64 { return int64(e.Depth) }},
+	"mode":   {typ: typeInt, num: func(e types.Entry) int64 { return infoOr(e, 0, perm) }},
+	"mtime":  {typ: typeTime, tim: func(e types.Entry) time.Time { return infoOr(e, time.Time{}, fs.FileInfo.ModTime) }},
+	"hidden": {typ: typeBool, bit: func(e types.Entry) bool { return strings.HasPrefix(entryName(e), ".") }},
+
+	// Тип содержимого, см. пакет filetype
+	"kind": {typ: typeString, str: func(e types.Entry) string { return e.Kind }},
+	"mime": {typ: typeString, str: func(e types.Entry) string { return e.MIME }},
+	"lang": {typ: typeString, str: func(e types.Entry) string { return e.Lang }},
+
+	// История git, заполняется только внутри репозитория
+	"commits":   {typ: typeInt, num: func(e types.Entry) int64 { return int64(e.Commits) }},
+	"committed": {typ: typeTime, tim: func(e types.Entry) time.Time { return e.LastCommit }},
+	"author":    {typ: typeString, str: func(e types.Entry) string { return e.LastAuthor }},
+}
+
+func init() {
+	for name, f := range fields {
+		f.name = name
+		fields[name] = f
+	}
+}
+
+// Значения поля type
+var entryTypes = []string{"file", "dir", "symlink"}
+
+// fieldNames возвращает имена полей по алфавиту для сообщений об ошибках
+func fieldNames() string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func infoOr[T any](e types.Entry, fallback T, get func(fs.FileInfo) T) T {
+	if e.Info == nil {
+		return fallback
+	}
+	return get(e.Info)
+}
+
+func entryName(e types.Entry) string {
+	return path.Base(filepath.ToSlash(e.Path))
+}
+
+// entryExt возвращает расширение без точки в нижнем регистре
+func entryExt(e types.Entry) string {
+	return strings.ToLower(strings.TrimPrefix(path.Ext(entryName(e)), "."))
+}
+
+func entryType(e types.Entry) string {
+	switch {
+	case e.IsSymlink() && (e.Info == nil || !e.Info.IsDir()):
+		return "symlink"
+	case e.Info != nil && e.Info.IsDir():
+		return "dir"
+	default:
+		return "file"
+	}
+}
+
+func perm(info fs.FileInfo) int64 {
+	return int64(info.Mode().Perm())
+}
+
+// kindNames возвращает значения поля kind для сообщений об ошибках
+func kindNames() string {
+	names := make([]string, len(filetype.Kinds))
+	for i, kind := range filetype.Kinds {
+		names[i] = string(kind)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,180 @@
+// Package filter реализует язык выражений --where для отбора записей:
+//
+//	size > 10MB && ext in ("go", "mod") && mtime < 30d && !hidden
+//
+// Поля: name, path, ext, type (file, dir, symlink), size, mtime, mode,
+// owner, depth, hidden, тип содержимого kind, mime и lang (см. пакет
+// filetype), а из истории git — commits, committed и author.
+// mtime и committed сравниваются с давностью (mtime < 30d — изменён
+// меньше 30 дней назад) или с датой ("2024-01-31"). @name подставляет
+// именованный фильтр из конфига.
+package filter
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/massonsky/gotree/internal/types"
+)
+
+// Expr скомпилированное выражение. Безопасно для использования
+// из нескольких горутин.
+type Expr struct {
+	src  string
+	root node
+	now  time.Time       // точка отсчёта давности mtime
+	used map[string]bool // поля, на которые ссылается выражение
+}
+
+// Compile разбирает выражение. named — именованные фильтры из конфига,
+// доступные в выражении как @name. Ошибка разбора — *SyntaxError.
+func Compile(src string, named map[string]string) (*Expr, error) {
+	used := make(map[string]bool)
+	root, err := compile(src, named, nil, used)
+	if err != nil {
+		return nil, err
+	}
+	return &Expr{src: src, root: root, now: time.Now(), used: used}, nil
+}
+
+// Uses сообщает, ссылается ли выражение хотя бы на одно из полей,
+// в том числе через именованные фильтры
+func (x *Expr) Uses(fields ...string) bool {
+	for _, name := range fields {
+		if x.used[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// Match сообщает, подходит ли запись под выражение
+func (x *Expr) Match(e types.Entry) bool {
+	return x.root.eval(e, x.now)
+}
+
+// String возвращает исходный текст выражения
+func (x *Expr) String() string {
+	return x.src
+}
+
+// node узел скомпилированного выражения
+type node interface {
+	eval(e types.Entry, now time.Time) bool
+}
+
+type andNode struct{ l, r node }
+
+func (n andNode) eval(e types.Entry, now time.Time) bool { return n.l.eval(e, now) && n.r.eval(e, now) }
+
+type orNode struct{ l, r node }
+
+func (n orNode) eval(e types.Entry, now time.Time) bool { return n.l.eval(e, now) || n.r.eval(e, now) }
+
+type notNode struct{ x node }
+
+func (n notNode) eval(e types.Entry, now time.Time) bool { return !n.x.eval(e, now) }
+
+type constNode bool
+
+func (n constNode) eval(types.Entry, time.Time) bool { return bool(n) }
+
+type boolField struct{ f field }
+
+func (n boolField) eval(e types.Entry, _ time.Time) bool { return n.f.bit(e) }
+
+type stringCmp struct {
+	f    field
+	op   string // == или !=
+	want string
+}
+
+func (n stringCmp) eval(e types.Entry, _ time.Time) bool {
+	return (n.f.str(e) == n.want) == (n.op == "==")
+}
+
+type regexCmp struct {
+	f      field
+	re     *regexp.Regexp
+	negate bool // !~
+}
+
+func (n regexCmp) eval(e types.Entry, _ time.Time) bool {
+	return n.re.MatchString(n.f.str(e)) != n.negate
+}
+
+type numberCmp struct {
+	f    field
+	op   string
+	want int64
+}
+
+func (n numberCmp) eval(e types.Entry, _ time.Time) bool {
+	got := n.f.num(e)
+	return compare(n.op, cmp3(got < n.want, got > n.want))
+}
+
+// ageCmp сравнивает давность изменения: mtime < 30d — моложе 30 дней
+type ageCmp struct {
+	f   field
+	op  string
+	age time.Duration
+}
+
+func (n ageCmp) eval(e types.Entry, now time.Time) bool {
+	got := now.Sub(n.f.tim(e))
+	return compare(n.op, cmp3(got < n.age, got > n.age))
+}
+
+// timeCmp сравнивает с датой: mtime > "2024-01-31" — позже даты
+type timeCmp struct {
+	f    field
+	op   string
+	want time.Time
+}
+
+func (n timeCmp) eval(e types.Entry, _ time.Time) bool {
+	got := n.f.tim(e)
+	return compare(n.op, cmp3(got.Before(n.want), got.After(n.want)))
+}
+
+type boolCmp struct {
+	f    field
+	op   string
+	want bool
+}
+
+func (n boolCmp) eval(e types.Entry, _ time.Time) bool {
+	return (n.f.bit(e) == n.want) == (n.op == "==")
+}
+
+func cmp3(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compare применяет оператор сравнения к результату cmp3
+func compare(op string, c int) bool {
+	switch op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	default:
+		return false
+	}
+}
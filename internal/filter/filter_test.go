@@ -0,0 +1,277 @@
+package filter
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/massonsky/gotree/internal/types"
+)
+
+// fileInfo сведения о записи для тестовых Entry
+type fileInfo struct {
+	name  string
+	size  int64
+	mode  fs.FileMode
+	mtime time.Time
+}
+
+func (f fileInfo) Name() string       { return f.name }
+func (f fileInfo) Size() int64        { return f.size }
+func (f fileInfo) Mode() fs.FileMode  { return f.mode }
+func (f fileInfo) ModTime() time.Time { return f.mtime }
+func (f fileInfo) IsDir() bool        { return f.mode.IsDir() }
+func (f fileInfo) Sys() any           { return nil }
+
+var (
+	now = time.Now()
+
+	goFile = types.Entry{
+		Path:  "src/main.go",
+		Depth: 2,
+		Info:  fileInfo{name: "main.go", size: 2048, mode: 0o644, mtime: now.Add(-48 * time.Hour)},
+		Kind:  "source",
+		Lang:  "go",
+
+		Commits:    3,
+		LastAuthor: "alice",
+		LastCommit: time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local),
+	}
+	srcDir = types.Entry{
+		Path:  "src",
+		Depth: 1,
+		Info:  fileInfo{name: "src", mode: fs.ModeDir | 0o755, mtime: now.Add(-400 * 24 * time.Hour)},
+	}
+	dotFile = types.Entry{
+		Path:  ".env",
+		Depth: 1,
+		Info:  fileInfo{name: ".env", size: 10, mode: 0o600, mtime: now},
+	}
+	link = types.Entry{
+		Path:       "latest",
+		Depth:      1,
+		Info:       fileInfo{name: "latest", mode: fs.ModeSymlink | 0o777, mtime: now},
+		LinkTarget: "src/main.go",
+	}
+)
+
+func TestMatch(t *testing.T) {
+	named := map[string]string{
+		"big":    "size > 1KB",
+		"golang": `lang == "go"`,
+		"both":   "@big && @golang",
+	}
+
+	tests := []struct {
+		expr  string
+		entry types.Entry
+		want  bool
+	}{
+		// Строки
+		{`name == "main.go"`, goFile, true},
+		{`name != "main.go"`, goFile, false},
+		{`path == "src/main.go"`, goFile, true},
+		{`ext == "go"`, goFile, true},
+		{`ext == ".GO"`, goFile, true},
+		{`ext in ("mod", "go")`, goFile, true},
+		{`ext not in ("mod", "go")`, goFile, false},
+		{`name =~ "^ma"`, goFile, true},
+		{`name !~ "_test"`, goFile, true},
+		{`type == "file"`, goFile, true},
+		{`type == "directory"`, srcDir, true},
+		{`type == "dir"`, goFile, false},
+		{`type == "symlink"`, link, true},
+		{`kind == "source" && lang == "go"`, goFile, true},
+		{`author == 'alice'`, goFile, true},
+		{`name == "a\"b"`, goFile, false},
+
+		// Числа и размеры
+		{"size > 1KB", goFile, true},
+		{"size >= 2KB", goFile, true},
+		{"size > 2KB", goFile, false},
+		{"size > 1.5kb", goFile, true},
+		{"size < 1MB", goFile, true},
+		{"size == 2048", goFile, true},
+		{"size in (1, 2048)", goFile, true},
+		{"mode == 0644", goFile, true},
+		{"mode == 644", goFile, false},
+		{"depth <= 1", goFile, false},
+		{"commits > 2", goFile, true},
+
+		// Время
+		{"mtime < 3d", goFile, true},
+		{"mtime < 1d", goFile, false},
+		{"mtime > 1y", srcDir, true},
+		{"mtime <= 60min", dotFile, true},
+		{`mtime > "2000-01-01"`, goFile, true},
+		{`committed < "2024-03-02"`, goFile, true},
+		{`committed > "2024-03-01T13:00"`, goFile, false},
+
+		// Логические поля
+		{"hidden", dotFile, true},
+		{"hidden", goFile, false},
+		{"!hidden", goFile, true},
+		{"hidden == false", goFile, true},
+		{"hidden != true", dotFile, false},
+
+		// Приоритет: ! сильнее &&, && сильнее ||
+		{"true || false && false", goFile, true},
+		{"(true || false) && false", goFile, false},
+		{"!true || true", goFile, true},
+		{"!(true || true)", goFile, false},
+		{"not false and false", goFile, false},
+		{"false or not false", goFile, true},
+		{"!!hidden", dotFile, true},
+		{`ext == "go" || hidden && size > 1MB`, goFile, true},
+		{`(ext == "go" || hidden) && size > 1MB`, goFile, false},
+
+		// Именованные фильтры
+		{"@big", goFile, true},
+		{"@big", dotFile, false},
+		{"@both", goFile, true},
+		{"@both && !@golang", goFile, false},
+		{"not @big", dotFile, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			x, err := Compile(tt.expr, named)
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			if got := x.Match(tt.entry); got != tt.want {
+				t.Errorf("Match(%s) = %v, want %v", tt.entry.Path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyntaxError(t *testing.T) {
+	tests := []struct {
+		expr string
+		pos  int    // смещение в байтах
+		msg  string // начало сообщения
+	}{
+		{"", 0, "empty expression"},
+		{"   ", 0, "empty expression"},
+		{"size >", 6, "size compares with a number, found end of expression"},
+		{"size > 10XB", 9, `unknown unit "XB"`},
+		{`nme == "x"`, 0, `unknown field "nme"`},
+		{"name == 'x", 8, "unterminated string"},
+		{"(size > 1", 9, `expected ")", found end of expression`},
+		{"size > 1 size", 9, `unexpected "size"`},
+		{"size > 1 &&", 11, "unexpected end of expression"},
+		{`name < "a"`, 5, "operator < is not supported for name"},
+		{"name", 4, "expected comparison after name, found end of expression"},
+		{"hidden == 1", 10, "hidden compares with true or false"},
+		{"hidden in (true)", 7, "hidden does not support in"},
+		{"@", 0, "expected filter name after @"},
+		{"size > 1 || @nope", 12, "unknown filter @nope"},
+		{`type == "pipe"`, 8, `unknown type "pipe"`},
+		{`kind == "blob"`, 8, `unknown kind "blob"`},
+		{`size in (1, "x")`, 12, `size compares with a number, found "x"`},
+		{`mtime > "yesterday"`, 8, `invalid date "yesterday"`},
+		{"mtime > 5", 8, "mtime compares with an age"},
+		{`name =~ "("`, 8, "invalid regexp"},
+		{"name ~ 1", 5, `unexpected character '~'`},
+		{"mode == 09", 8, `invalid integer "09"`},
+		{")", 0, `unexpected ")"`},
+		{`name == "файл" && `, 22, "unexpected end of expression"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Compile(tt.expr, nil)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("error = %v, want *SyntaxError", err)
+			}
+			if syntaxErr.Pos != tt.pos {
+				t.Errorf("pos = %d, want %d", syntaxErr.Pos, tt.pos)
+			}
+			if !strings.HasPrefix(syntaxErr.Msg, tt.msg) {
+				t.Errorf("msg = %q, want prefix %q", syntaxErr.Msg, tt.msg)
+			}
+		})
+	}
+}
+
+func TestSyntaxErrorColumn(t *testing.T) {
+	// Колонка считается в символах, а не байтах: "файл" — 4 символа, 8 байт
+	expr := `name == "файл" && `
+	_, err := Compile(expr, nil)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("error has %d lines, want 3:\n%s", len(lines), err)
+	}
+	if !strings.HasSuffix(lines[0], "(column 19)") {
+		t.Errorf("first line = %q, want column 19", lines[0])
+	}
+	if lines[1] != "  "+expr {
+		t.Errorf("second line = %q, want the expression", lines[1])
+	}
+	if want := "  " + strings.Repeat(" ", 18) + "^"; lines[2] != want {
+		t.Errorf("pointer line = %q, want %q", lines[2], want)
+	}
+}
+
+func TestNamedFilterErrors(t *testing.T) {
+	named := map[string]string{
+		"bad":   "size >",
+		"loop":  "@loop2",
+		"loop2": "hidden || @loop",
+		"self":  "@self",
+	}
+
+	tests := []struct {
+		expr     string
+		wantExpr string // выражение, на которое указывает SyntaxError
+		wantPos  int
+		msg      string
+	}{
+		{"hidden && @bad", "size >", 6, "in filter @bad: size compares with a number"},
+		{"@loop", "hidden || @loop", 10, "in filter @loop: in filter @loop2: filter @loop refers to itself"},
+		{"@self", "@self", 0, "in filter @self: filter @self refers to itself"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Compile(tt.expr, named)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.HasPrefix(err.Error(), tt.msg) {
+				t.Errorf("error = %q, want prefix %q", err, tt.msg)
+			}
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("error = %v, want to wrap *SyntaxError", err)
+			}
+			if syntaxErr.Expr != tt.wantExpr || syntaxErr.Pos != tt.wantPos {
+				t.Errorf("points at %q:%d, want %q:%d", syntaxErr.Expr, syntaxErr.Pos, tt.wantExpr, tt.wantPos)
+			}
+		})
+	}
+}
+
+func TestUses(t *testing.T) {
+	named := map[string]string{"golang": `lang == "go"`}
+	x, err := Compile(`@golang && size > 1KB`, named)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for field, want := range map[string]bool{"lang": true, "size": true, "commits": false, "mtime": false} {
+		if got := x.Uses(field); got != want {
+			t.Errorf("Uses(%q) = %v, want %v", field, got, want)
+		}
+	}
+	if !x.Uses("author", "size") {
+		t.Error("Uses with several fields should report any of them")
+	}
+}
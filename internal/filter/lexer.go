@@ -0,0 +1,187 @@
+package filter
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF      tokenKind = iota
+	tokIdent              // имя поля или ключевое слово
+	tokNumber             // 10, 0644, 10MB
+	tokDuration           // 30d, 12h
+	tokString             // "go" или 'go'
+	tokRef                // @name — именованный фильтр из конфига
+	tokOp                 // && || ! == != < <= > >= =~ !~ ( ) ,
+)
+
+type token struct {
+	kind tokenKind
+	text string // исходный текст токена
+	pos  int    // смещение в байтах от начала выражения
+	num  int64  // значение tokNumber
+	dur  time.Duration
+	str  string // значение tokString без кавычек, имя tokRef
+}
+
+// Множители размеров. Как и в выводе размеров, KB = 1024 байта.
+var sizeUnits = map[string]int64{
+	"b": 1,
+	"k": 1 << 10, "kb": 1 << 10, "kib": 1 << 10,
+	"m": 1 << 20, "mb": 1 << 20, "mib": 1 << 20,
+	"g": 1 << 30, "gb": 1 << 30, "gib": 1 << 30,
+	"t": 1 << 40, "tb": 1 << 40, "tib": 1 << 40,
+}
+
+// Единицы давности для mtime. "m" занята мегабайтами, минуты — "min".
+var durationUnits = map[string]time.Duration{
+	"s":   time.Second,
+	"min": time.Minute,
+	"h":   time.Hour,
+	"d":   24 * time.Hour,
+	"w":   7 * 24 * time.Hour,
+	"y":   365 * 24 * time.Hour,
+}
+
+// Операторы; двухсимвольные проверяются первыми
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "!~", "!", "<", ">", "(", ")", ","}
+
+// lex разбивает выражение на токены
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+
+		case r == '"' || r == '\'':
+			tok, err := lexString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i += len(tok.text)
+
+		case r >= '0' && r <= '9':
+			tok, err := lexNumber(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i += len(tok.text)
+
+		case r == '@':
+			end := i + 1 + identLen(src[i+1:], true)
+			if end == i+1 {
+				return nil, errorAt(src, i, "expected filter name after @")
+			}
+			tokens = append(tokens, token{kind: tokRef, text: src[i:end], pos: i, str: src[i+1 : end]})
+			i = end
+
+		case isIdentStart(r):
+			end := i + identLen(src[i:], false)
+			tokens = append(tokens, token{kind: tokIdent, text: src[i:end], pos: i})
+			i = end
+
+		default:
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, errorAt(src, i, "unexpected character %q", r)
+			}
+			tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+// identLen возвращает длину имени; в именах фильтров допустим дефис
+func identLen(s string, dash bool) int {
+	n := 0
+	for n < len(s) {
+		r, size := utf8.DecodeRuneInString(s[n:])
+		if !isIdentStart(r) && !unicode.IsDigit(r) && !(dash && r == '-') {
+			break
+		}
+		n += size
+	}
+	return n
+}
+
+// lexString читает строку в кавычках; внутри поддерживается \" и \\
+func lexString(src string, start int) (token, error) {
+	quote := src[start]
+	var b strings.Builder
+	for i := start + 1; i < len(src); i++ {
+		switch c := src[i]; {
+		case c == '\\' && i+1 < len(src):
+			i++
+			b.WriteByte(src[i])
+		case c == quote:
+			return token{kind: tokString, text: src[start : i+1], pos: start, str: b.String()}, nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return token{}, errorAt(src, start, "unterminated string")
+}
+
+// lexNumber читает число с необязательной единицей размера или давности.
+// Число с ведущим нулём без единицы восьмеричное, как права доступа.
+func lexNumber(src string, start int) (token, error) {
+	i := start
+	for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+		i++
+	}
+	digits := src[start:i]
+	unitStart := i
+	for i < len(src) && unicode.IsLetter(rune(src[i])) {
+		i++
+	}
+	unit := strings.ToLower(src[unitStart:i])
+	tok := token{text: src[start:i], pos: start}
+
+	value, err := strconv.ParseFloat(digits, 64)
+	if err != nil {
+		return token{}, errorAt(src, start, "invalid number %q", digits)
+	}
+
+	switch {
+	case unit == "":
+		tok.kind = tokNumber
+		base := 10
+		if len(digits) > 1 && digits[0] == '0' {
+			base = 8
+		}
+		n, err := strconv.ParseInt(digits, base, 64)
+		if err != nil {
+			return token{}, errorAt(src, start, "invalid integer %q", digits)
+		}
+		tok.num = n
+	case sizeUnits[unit] != 0:
+		tok.kind = tokNumber
+		tok.num = int64(value * float64(sizeUnits[unit]))
+	case durationUnits[unit] != 0:
+		tok.kind = tokDuration
+		tok.dur = time.Duration(value * float64(durationUnits[unit]))
+	default:
+		return token{}, errorAt(src, unitStart, "unknown unit %q (sizes: B, KB, MB, GB, TB; ages: s, min, h, d, w, y)", src[unitStart:i])
+	}
+	return tok, nil
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package filter
+
+import "io/fs"
+
+// owner не поддерживается вне unix: поле owner всегда пустое
+func owner(fs.FileInfo) string {
+	return ""
+}
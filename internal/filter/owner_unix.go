@@ -0,0 +1,36 @@
+//go:build unix
+
+package filter
+
+import (
+	"io/fs"
+	"os/user"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+// owners кэширует имена пользователей по uid: поиск в /etc/passwd
+// на каждую запись сильно замедлил бы обход
+var owners sync.Map
+
+// owner возвращает имя владельца файла или uid, если имя неизвестно
+func owner(info fs.FileInfo) string {
+	if info == nil {
+		return ""
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+	if name, ok := owners.Load(stat.Uid); ok {
+		return name.(string)
+	}
+
+	name := strconv.FormatUint(uint64(stat.Uid), 10)
+	if u, err := user.LookupId(name); err == nil {
+		name = u.Username
+	}
+	owners.Store(stat.Uid, name)
+	return name
+}
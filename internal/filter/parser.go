@@ -0,0 +1,309 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/massonsky/gotree/internal/filetype"
+)
+
+// Грамматика, от низшего приоритета к высшему:
+//
+//	or      = and { ("||" | "or") and }
+//	and     = unary { ("&&" | "and") unary }
+//	unary   = ("!" | "not") unary | primary
+//	primary = "(" or ")" | "@" name | "true" | "false" | field [ cmp ]
+//	cmp     = op value | ["not"] "in" "(" value { "," value } ")"
+type parser struct {
+	src    string
+	tokens []token
+	pos    int
+	named  map[string]string
+	stack  []string        // раскрываемые именованные фильтры, для поиска циклов
+	used   map[string]bool // поля, встретившиеся в выражении, см. Expr.Uses
+}
+
+// Форматы дат, которые понимает сравнение mtime со строкой
+var dateLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"}
+
+func compile(src string, named map[string]string, stack []string, used map[string]bool) (node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{src: src, tokens: tokens, named: named, stack: stack, used: used}
+	if p.peek().kind == tokEOF {
+		return nil, errorAt(src, 0, "empty expression")
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+	return root, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept съедает оператор или ключевое слово, если оно следующее
+func (p *parser) accept(texts ...string) bool {
+	tok := p.peek()
+	if (tok.kind == tokOp || tok.kind == tokIdent) && slices.Contains(texts, tok.text) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		tok := p.peek()
+		return p.errorf(tok, "expected %q, found %s", text, describe(tok))
+	}
+	return nil
+}
+
+func (p *parser) errorf(tok token, format string, args ...any) error {
+	return errorAt(p.src, tok.pos, format, args...)
+}
+
+func describe(tok token) string {
+	switch tok.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return tok.text
+	}
+	return fmt.Sprintf("%q", tok.text)
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.accept("||", "or") {
+		var right node
+		right, err = p.parseAnd()
+		left = orNode{left, right}
+	}
+	return left, err
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	for err == nil && p.accept("&&", "and") {
+		var right node
+		right, err = p.parseUnary()
+		left = andNode{left, right}
+	}
+	return left, err
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.accept("!", "not") {
+		x, err := p.parseUnary()
+		return notNode{x}, err
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokOp:
+		if tok.text != "(" {
+			return nil, p.errorf(tok, "unexpected %q", tok.text)
+		}
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return x, p.expect(")")
+
+	case tokRef:
+		return p.parseRef(tok)
+
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return constNode(true), nil
+		case "false":
+			return constNode(false), nil
+		}
+		f, ok := fields[tok.text]
+		if !ok {
+			return nil, p.errorf(tok, "unknown field %q (fields: %s)", tok.text, fieldNames())
+		}
+		p.used[f.name] = true
+		return p.parseComparison(f, tok)
+
+	case tokEOF:
+		return nil, p.errorf(tok, "unexpected end of expression")
+
+	default:
+		return nil, p.errorf(tok, "expected field name, found %s", describe(tok))
+	}
+}
+
+// parseRef подставляет именованный фильтр
+func (p *parser) parseRef(tok token) (node, error) {
+	src, ok := p.named[tok.str]
+	if !ok {
+		return nil, p.errorf(tok, "unknown filter @%s", tok.str)
+	}
+	if slices.Contains(p.stack, tok.str) {
+		return nil, p.errorf(tok, "filter @%s refers to itself", tok.str)
+	}
+
+	x, err := compile(src, p.named, append(slices.Clip(p.stack), tok.str), p.used)
+	if err != nil {
+		return nil, fmt.Errorf("in filter @%s: %w", tok.str, err)
+	}
+	return x, nil
+}
+
+func (p *parser) parseComparison(f field, fieldTok token) (node, error) {
+	opTok := p.peek()
+	switch {
+	case p.accept("in"):
+		return p.parseIn(f)
+	case opTok.kind == tokIdent && opTok.text == "not":
+		p.next()
+		if err := p.expect("in"); err != nil {
+			return nil, err
+		}
+		x, err := p.parseIn(f)
+		return notNode{x}, err
+	case opTok.kind == tokOp && slices.Contains([]string{"==", "!=", "<", "<=", ">", ">=", "=~", "!~"}, opTok.text):
+		p.next()
+		return p.parseValue(f, opTok.text, opTok)
+	}
+
+	if f.typ == typeBool {
+		return boolField{f}, nil
+	}
+	return nil, p.errorf(opTok, "expected comparison after %s, found %s", fieldTok.text, describe(opTok))
+}
+
+// parseIn разбирает список значений; "x in (a, b)" равносильно "x == a || x == b"
+func (p *parser) parseIn(f field) (node, error) {
+	if f.typ != typeString && f.typ != typeInt {
+		return nil, p.errorf(p.tokens[p.pos-1], "%s does not support in", f.name)
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	var x node
+	for {
+		eq, err := p.parseValue(f, "==", p.peek())
+		if err != nil {
+			return nil, err
+		}
+		if x == nil {
+			x = eq
+		} else {
+			x = orNode{x, eq}
+		}
+		if !p.accept(",") {
+			break
+		}
+	}
+	return x, p.expect(")")
+}
+
+// parseValue разбирает значение справа от оператора с учётом типа поля
+func (p *parser) parseValue(f field, op string, opTok token) (node, error) {
+	tok := p.next()
+	switch f.typ {
+	case typeString:
+		if tok.kind != tokString {
+			return nil, p.errorf(tok, "%s compares with a quoted string, found %s", f.name, describe(tok))
+		}
+		switch op {
+		case "=~", "!~":
+			re, err := regexp.Compile(tok.str)
+			if err != nil {
+				return nil, p.errorf(tok, "invalid regexp: %v", err)
+			}
+			return regexCmp{f: f, re: re, negate: op == "!~"}, nil
+		case "==", "!=":
+			want, err := p.normalize(f, tok)
+			return stringCmp{f: f, op: op, want: want}, err
+		}
+
+	case typeInt:
+		if op == "=~" || op == "!~" {
+			break
+		}
+		if tok.kind != tokNumber {
+			return nil, p.errorf(tok, "%s compares with a number, found %s", f.name, describe(tok))
+		}
+		return numberCmp{f: f, op: op, want: tok.num}, nil
+
+	case typeTime:
+		if op == "=~" || op == "!~" {
+			break
+		}
+		switch tok.kind {
+		case tokDuration:
+			return ageCmp{f: f, op: op, age: tok.dur}, nil
+		case tokString:
+			for _, layout := range dateLayouts {
+				if t, err := time.ParseInLocation(layout, tok.str, time.Local); err == nil {
+					return timeCmp{f: f, op: op, want: t}, nil
+				}
+			}
+			return nil, p.errorf(tok, "invalid date %q (use YYYY-MM-DD or RFC 3339)", tok.str)
+		}
+		return nil, p.errorf(tok, "%s compares with an age like 30d or a date like \"2024-01-31\", found %s", f.name, describe(tok))
+
+	case typeBool:
+		if op != "==" && op != "!=" {
+			break
+		}
+		if tok.kind != tokIdent || (tok.text != "true" && tok.text != "false") {
+			return nil, p.errorf(tok, "%s compares with true or false, found %s", f.name, describe(tok))
+		}
+		return boolCmp{f: f, op: op, want: tok.text == "true"}, nil
+	}
+	return nil, p.errorf(opTok, "operator %s is not supported for %s", op, f.name)
+}
+
+// normalize приводит строковое значение к виду, в котором его отдаёт поле
+func (p *parser) normalize(f field, tok token) (string, error) {
+	switch f.name {
+	case "ext":
+		return strings.ToLower(strings.TrimPrefix(tok.str, ".")), nil
+	case "type":
+		want := strings.ToLower(tok.str)
+		if want == "directory" {
+			want = "dir"
+		}
+		if !slices.Contains(entryTypes, want) {
+			return "", p.errorf(tok, "unknown type %q (types: %s)", tok.str, strings.Join(entryTypes, ", "))
+		}
+		return want, nil
+	case "kind":
+		want := strings.ToLower(tok.str)
+		if !slices.Contains(filetype.Kinds, filetype.Kind(want)) {
+			return "", p.errorf(tok, "unknown kind %q (kinds: %s)", tok.str, kindNames())
+		}
+		return want, nil
+	}
+	return tok.str, nil
+}
@@ -0,0 +1,173 @@
+// Package gitstatus читает состояние файлов рабочего дерева через
+// git status и сводит его к двум буквам на запись, как eza --git:
+// первая — изменения в индексе (staged), вторая — в рабочем дереве.
+//
+//	N новый, M изменён, D удалён, R переименован, T сменил тип,
+//	U конфликт, I игнорируется, - без изменений
+//
+// LoadHistory дополнительно читает из git log последний коммит,
+// автора и число коммитов для каждого файла.
+package gitstatus
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Состояния, которые не зависят от индекса
+const (
+	Clean     = "--"
+	Untracked = "-N"
+	Ignored   = "-I"
+	Conflict  = "UU"
+)
+
+// ErrNotRepository директория не лежит внутри рабочего дерева git
+var ErrNotRepository = errors.New("not a git repository")
+
+// Repo состояние файлов одного репозитория на момент Load
+type Repo struct {
+	Root string // корень рабочего дерева
+
+	files   map[string]string // путь от корня → состояние, только не Clean
+	ignored map[string]bool   // директории, игнорируемые целиком
+}
+
+// Load запускает git status для поддерева dir. Нужен установленный git.
+func Load(ctx context.Context, dir string) (*Repo, error) {
+	out, err := git(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	repo := &Repo{
+		Root:    filepath.FromSlash(strings.TrimSpace(string(out))),
+		files:   make(map[string]string),
+		ignored: make(map[string]bool),
+	}
+
+	// В формате porcelain пути всегда от корня репозитория, даже при -C
+	out, err = git(ctx, dir, "status", "--porcelain=v1", "-z",
+		"--untracked-files=all", "--ignored=matching", "--", ".")
+	if err != nil {
+		return nil, err
+	}
+	repo.parse(out)
+	return repo, nil
+}
+
+// git выполняет команду в директории dir и возвращает stdout
+func git(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if strings.Contains(msg, "not a git repository") {
+			return nil, ErrNotRepository
+		}
+		if msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// parse разбирает вывод git status --porcelain=v1 -z. У переименования
+// и копии — в индексе или в рабочем дереве — за новым путём следует
+// отдельное поле со старым.
+func (r *Repo) parse(out []byte) {
+	fields := strings.Split(string(out), "\x00")
+	for i := 0; i < len(fields); i++ {
+		record := fields[i]
+		if len(record) < 4 {
+			continue
+		}
+		x, y, name := record[0], record[1], record[3:]
+		if x == 'R' || x == 'C' || y == 'R' || y == 'C' {
+			i++
+		}
+
+		var status string
+		switch {
+		case x == '?':
+			status = Untracked
+		case x == '!':
+			status = Ignored
+		case x == 'U' || y == 'U' || (x == 'A' && y == 'A') || (x == 'D' && y == 'D'):
+			status = Conflict
+		default:
+			status = string([]byte{letter(x), letter(y)})
+		}
+
+		if dir, ok := strings.CutSuffix(name, "/"); ok {
+			if status == Ignored {
+				r.ignored[dir] = true
+			}
+			continue
+		}
+		r.files[name] = status
+	}
+}
+
+// letter переводит букву git status в обозначение eza
+func letter(c byte) byte {
+	switch c {
+	case ' ':
+		return '-'
+	case 'A', 'C':
+		return 'N'
+	default:
+		return c
+	}
+}
+
+// Status возвращает состояние записи по пути от корня репозитория
+// в формате со слешами. Для директорий это только признак Ignored,
+// остальное сводится из детей через Merge.
+func (r *Repo) Status(rel string) string {
+	if status, ok := r.files[rel]; ok {
+		return status
+	}
+	for dir := rel; dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if r.ignored[dir] {
+			return Ignored
+		}
+	}
+	return Clean
+}
+
+// rank порядок важности букв: в сводке по директории побеждает старшая.
+// Игнорируемые файлы не делают директорию игнорируемой.
+const rank = "I-NTRMDU"
+
+// Merge сводит состояния двух записей в состояние директории
+func Merge(a, b string) string {
+	if len(a) != 2 {
+		return b
+	}
+	if len(b) != 2 {
+		return a
+	}
+	out := []byte(a)
+	for i := range 2 {
+		if strings.IndexByte(rank, b[i]) > strings.IndexByte(rank, out[i]) {
+			out[i] = b[i]
+		}
+	}
+	return string(out)
+}
+
+// Changed сообщает, есть ли у записи изменения: новые, изменённые,
+// конфликтующие и прочие, кроме чистых и игнорируемых
+func Changed(status string) bool {
+	return status != "" && status != Clean && status != Ignored
+}
@@ -0,0 +1,134 @@
+package gitstatus
+
+import (
+	"maps"
+	"testing"
+)
+
+func newRepo(out string) *Repo {
+	r := &Repo{files: make(map[string]string), ignored: make(map[string]bool)}
+	r.parse([]byte(out))
+	return r
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		out     string
+		files   map[string]string
+		ignored []string
+	}{
+		{"empty", "", map[string]string{}, nil},
+		{
+			name: "index and worktree",
+			out:  "M  staged.go\x00 M edited.go\x00MM both.go\x00A  added.go\x00 D gone.go\x00 T type.go\x00",
+			files: map[string]string{
+				"staged.go": "M-",
+				"edited.go": "-M",
+				"both.go":   "MM",
+				"added.go":  "N-",
+				"gone.go":   "-D",
+				"type.go":   "-T",
+			},
+		},
+		{
+			name:    "untracked and ignored",
+			out:     "?? new.txt\x00!! debug.log\x00!! build/\x00?? sub/dir/x\x00",
+			files:   map[string]string{"new.txt": Untracked, "debug.log": Ignored, "sub/dir/x": Untracked},
+			ignored: []string{"build"},
+		},
+		{
+			name:  "conflicts",
+			out:   "UU a\x00AA b\x00DD c\x00AU d\x00UD e\x00",
+			files: map[string]string{"a": Conflict, "b": Conflict, "c": Conflict, "d": Conflict, "e": Conflict},
+		},
+		{
+			// Старый путь идёт отдельным полем и не становится записью
+			name:  "rename and copy",
+			out:   "R  new.go\x00old.go\x00C  copy.go\x00orig.go\x00RM moved.go\x00was.go\x00 M after.go\x00",
+			files: map[string]string{"new.go": "R-", "copy.go": "N-", "moved.go": "RM", "after.go": "-M"},
+		},
+		{
+			name:  "rename in worktree",
+			out:   " R renamed.go\x00source.go\x00?? tail\x00",
+			files: map[string]string{"renamed.go": "-R", "tail": Untracked},
+		},
+		{
+			// Старый путь, похожий на запись, не разбирается как запись
+			name:  "old path looks like a record",
+			out:   "R  b\x00?? a\x00",
+			files: map[string]string{"b": "R-"},
+		},
+		{
+			name:  "spaces and unicode in names",
+			out:   " M dir with space/файл.txt\x00R  new name\x00old name\x00",
+			files: map[string]string{"dir with space/файл.txt": "-M", "new name": "R-"},
+		},
+		{
+			name:  "short and trailing fields",
+			out:   "M\x00?? \x00 M ok\x00\x00",
+			files: map[string]string{"ok": "-M"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRepo(tt.out)
+			if !maps.Equal(r.files, tt.files) {
+				t.Errorf("files = %q, want %q", r.files, tt.files)
+			}
+			if len(r.ignored) != len(tt.ignored) {
+				t.Errorf("ignored dirs = %v, want %q", r.ignored, tt.ignored)
+			}
+			for _, dir := range tt.ignored {
+				if !r.ignored[dir] {
+					t.Errorf("%s is not ignored", dir)
+				}
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	r := newRepo(" M src/main.go\x00!! build/\x00!! src/gen/\x00")
+
+	tests := []struct {
+		rel  string
+		want string
+	}{
+		{"src/main.go", "-M"},
+		{"src/other.go", Clean},
+		{"build", Ignored},
+		{"build/out/app", Ignored},
+		{"src/gen/x.go", Ignored},
+		{"buildx", Clean},
+		{".", Clean},
+	}
+	for _, tt := range tests {
+		if got := r.Status(tt.rel); got != tt.want {
+			t.Errorf("Status(%q) = %q, want %q", tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"", "-M", "-M"},
+		{"M-", "", "M-"},
+		{Clean, "-M", "-M"},
+		{"M-", "-N", "MN"},
+		{"-M", "-N", "-M"},
+		{Ignored, Clean, Clean},
+		{Ignored, Untracked, Untracked},
+		{"R-", "M-", "M-"},
+		{"MM", Conflict, Conflict},
+	}
+	for _, tt := range tests {
+		if got := Merge(tt.a, tt.b); got != tt.want {
+			t.Errorf("Merge(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
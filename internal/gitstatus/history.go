@@ -0,0 +1,97 @@
+package gitstatus
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileHistory сводка истории файла или директории
+type FileHistory struct {
+	LastCommit time.Time // время последнего коммита (committer date)
+	LastAuthor string    // автор последнего коммита
+	Commits    int       // число коммитов, затронувших файл или поддерево
+}
+
+// History история всех файлов поддерева, прочитанная одним git log
+type History struct {
+	Root string // корень рабочего дерева
+
+	files map[string]*FileHistory // путь от корня репозитория → сводка
+}
+
+// LoadHistory читает историю поддерева dir одним вызовом git log.
+// Переименования не отслеживаются: история привязана к текущему пути.
+func LoadHistory(ctx context.Context, dir string) (*History, error) {
+	out, err := git(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	h := &History{
+		Root:  filepath.FromSlash(strings.TrimSpace(string(out))),
+		files: make(map[string]*FileHistory),
+	}
+
+	// Коммит начинается с \x1e, время и автор разделены \x1f,
+	// дальше через \x00 идут изменённые пути от корня репозитория
+	out, err = git(ctx, dir, "log", "--no-renames", "--format=%x1e%ct%x1f%an", "--name-only", "-z", "--", ".")
+	if err != nil {
+		return nil, err
+	}
+	h.parse(string(out))
+	return h, nil
+}
+
+// parse разбирает вывод git log. Коммиты идут от новых к старым, поэтому
+// первый встреченный коммит пути — последний. Директория получает сводку
+// по всем файлам внутри, каждый коммит считается в ней один раз.
+func (h *History) parse(out string) {
+	for _, commit := range strings.Split(out, "\x1e") {
+		header, files, _ := strings.Cut(commit, "\x00")
+		stamp, author, ok := strings.Cut(header, "\x1f")
+		if !ok {
+			continue
+		}
+		unix, err := strconv.ParseInt(stamp, 10, 64)
+		if err != nil {
+			continue
+		}
+		when := time.Unix(unix, 0)
+
+		touched := make(map[string]bool)
+		for _, name := range strings.Split(files, "\x00") {
+			name = strings.TrimLeft(name, "\n")
+			if name == "" {
+				continue
+			}
+			touched[name] = true
+			for dir := path.Dir(name); !touched[dir]; dir = path.Dir(dir) {
+				touched[dir] = true
+				if dir == "." {
+					break
+				}
+			}
+		}
+		for name := range touched {
+			fh, ok := h.files[name]
+			if !ok {
+				fh = &FileHistory{LastCommit: when, LastAuthor: author}
+				h.files[name] = fh
+			}
+			fh.Commits++
+		}
+	}
+}
+
+// Lookup возвращает сводку по пути от корня репозитория в формате
+// со слешами ("." — корень). false — путь не встречается в истории.
+func (h *History) Lookup(rel string) (FileHistory, bool) {
+	fh, ok := h.files[rel]
+	if !ok {
+		return FileHistory{}, false
+	}
+	return *fh, true
+}
@@ -0,0 +1,84 @@
+package ignore
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Имена файлов правил, которые читаются в каждой директории.
+// .gotreeignore читается последним и поэтому важнее .gitignore.
+var PerDirFiles = []string{".gitignore", ".gotreeignore"}
+
+// RepoExcludeFile путь к правилам репозитория относительно его корня
+var RepoExcludeFile = filepath.Join(".git", "info", "exclude")
+
+// GlobalExcludesFile возвращает путь к глобальному файлу исключений git:
+// core.excludesFile из ~/.gitconfig, иначе $XDG_CONFIG_HOME/git/ignore
+func GlobalExcludesFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	if p := readExcludesFile(filepath.Join(home, ".gitconfig")); p != "" {
+		if strings.HasPrefix(p, "~/") {
+			p = filepath.Join(home, p[2:])
+		}
+		return p
+	}
+
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "git", "ignore")
+}
+
+// readExcludesFile достаёт core.excludesFile из файла настроек git.
+// Поддерживается только простой формат "key = value" без include.
+func readExcludesFile(gitconfig string) string {
+	f, err := os.Open(gitconfig)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	inCore := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			inCore = strings.EqualFold(strings.Trim(line, "[] \t"), "core")
+			continue
+		}
+		if !inCore {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if ok && strings.EqualFold(strings.TrimSpace(key), "excludesfile") {
+			return strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return ""
+}
+
+// FindRepoRoot ищет ближайшую директорию с .git, начиная с dir и выше.
+// Возвращает пустую строку, если репозиторий не найден.
+func FindRepoRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
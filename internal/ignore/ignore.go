@@ -0,0 +1,218 @@
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Pattern одно правило из файла в формате .gitignore
+type Pattern struct {
+	negate   bool // правило начинается с "!" и возвращает путь обратно
+	dirOnly  bool // правило заканчивается на "/" и подходит только директориям
+	anchored bool // правило содержит "/" и сопоставляется с путём от базы
+	re       *regexp.Regexp
+}
+
+// Matcher цепочка наборов правил: каждый уровень директорий добавляет
+// свой набор поверх родительского. Matcher не изменяется после создания,
+// поэтому его безопасно использовать из нескольких горутин.
+type Matcher struct {
+	parent   *Matcher
+	base     string // директория файла правил относительно корня сопоставления, "" — корень
+	patterns []Pattern
+}
+
+// Child возвращает новый уровень с правилами для директории base.
+// Если правил нет, возвращается текущий уровень.
+func (m *Matcher) Child(base string, patterns []Pattern) *Matcher {
+	if len(patterns) == 0 {
+		return m
+	}
+	return &Matcher{parent: m, base: base, patterns: patterns}
+}
+
+// Match сообщает, исключён ли путь. Путь задаётся через "/" относительно
+// корня сопоставления. Более глубокие уровни важнее родительских, внутри
+// уровня побеждает последнее подходящее правило.
+func (m *Matcher) Match(p string, isDir bool) bool {
+	for level := m; level != nil; level = level.parent {
+		rel := p
+		if level.base != "" {
+			if !strings.HasPrefix(p, level.base+"/") {
+				continue
+			}
+			rel = p[len(level.base)+1:]
+		}
+
+		for i := len(level.patterns) - 1; i >= 0; i-- {
+			if level.patterns[i].match(rel, isDir) {
+				return !level.patterns[i].negate
+			}
+		}
+	}
+	return false
+}
+
+func (p Pattern) match(rel string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	if p.anchored {
+		return p.re.MatchString(rel)
+	}
+	// Правило без "/" подходит к имени на любом уровне ниже базы
+	return p.re.MatchString(path.Base(rel))
+}
+
+// ParseFile читает файл правил. Отсутствующий файл не считается ошибкой.
+func ParseFile(filename string) ([]Pattern, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// ParseFS читает файл правил из fs.FS. Отсутствующий файл не считается ошибкой.
+func ParseFS(fsys fs.FS, name string) ([]Pattern, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse разбирает правила построчно
+func Parse(r io.Reader) ([]Pattern, error) {
+	var patterns []Pattern
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if p, ok := ParseLine(scanner.Text()); ok {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns, scanner.Err()
+}
+
+// ParseLine разбирает одну строку. ok == false для пустых строк и комментариев.
+func ParseLine(line string) (Pattern, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	line = trimTrailingSpaces(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return Pattern{}, false
+	}
+
+	var p Pattern
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		p.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return Pattern{}, false
+	}
+
+	re, err := regexp.Compile("^" + globToRegexp(line) + "$")
+	if err != nil {
+		return Pattern{}, false
+	}
+	p.re = re
+	return p, true
+}
+
+// trimTrailingSpaces убирает хвостовые пробелы, кроме экранированных "\ "
+func trimTrailingSpaces(s string) string {
+	for strings.HasSuffix(s, " ") && !strings.HasSuffix(s, `\ `) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// globToRegexp переводит шаблон gitignore в регулярное выражение:
+// "*" и "?" не пересекают "/", "**" между слэшами означает любое
+// количество директорий (включая ноль).
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				atStart := i == 0 || glob[i-1] == '/'
+				j := i + 2
+				atEnd := j == len(glob)
+				if atStart && atEnd {
+					b.WriteString(".*")
+					i = j - 1
+					continue
+				}
+				if atStart && glob[j] == '/' {
+					b.WriteString("(?:.*/)?")
+					i = j
+					continue
+				}
+				// Прочие "**" — обычная звёздочка
+				for i+1 < len(glob) && glob[i+1] == '*' {
+					i++
+				}
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if end == 0 {
+				// "[]...]" — закрывающая скобка как первый символ класса
+				rest := strings.IndexByte(glob[i+2:], ']')
+				if rest < 0 {
+					b.WriteString(`\[`)
+					continue
+				}
+				class = glob[i+1 : i+2+rest]
+				end = rest + 1
+			}
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,164 @@
+package ignore
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob    string
+		match   []string
+		noMatch []string
+	}{
+		{"*.log", []string{"a.log", ".log"}, []string{"a.log.gz", "dir/a.log"}},
+		{"file?.txt", []string{"file1.txt"}, []string{"file.txt", "file12.txt", "file/.txt"}},
+		{"[abc].go", []string{"a.go", "c.go"}, []string{"d.go", "ab.go"}},
+		{"[!abc].go", []string{"d.go"}, []string{"a.go"}},
+		{"[a-c]x", []string{"bx"}, []string{"dx"}},
+		{"[]]x", []string{"]x"}, []string{"ax"}},
+		{"[x", []string{"[x"}, []string{"x"}},
+		{"**/logs", []string{"logs", "a/logs", "a/b/logs"}, []string{"alogs", "logs/a"}},
+		{"logs/**", []string{"logs/a", "logs/a/b"}, []string{"logs", "a/logs/b"}},
+		{"a/**/b", []string{"a/b", "a/x/b", "a/x/y/b"}, []string{"ab", "a/xb", "b"}},
+		{"**", []string{"a", "a/b/c"}, nil},
+		{"a**b", []string{"ab", "axxb"}, []string{"a/b"}},
+		{`\*.txt`, []string{"*.txt"}, []string{"a.txt"}},
+		{`\#notes`, []string{"#notes"}, []string{"notes"}},
+		{"a.b+c", []string{"a.b+c"}, []string{"axb+c", "a.bbc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.glob, func(t *testing.T) {
+			re := regexp.MustCompile("^" + globToRegexp(tt.glob) + "$")
+			for _, s := range tt.match {
+				if !re.MatchString(s) {
+					t.Errorf("%q does not match %q (regexp %s)", tt.glob, s, re)
+				}
+			}
+			for _, s := range tt.noMatch {
+				if re.MatchString(s) {
+					t.Errorf("%q matches %q (regexp %s)", tt.glob, s, re)
+				}
+			}
+		})
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line string
+		ok   bool
+		want Pattern // без re
+	}{
+		{line: "", ok: false},
+		{line: "   ", ok: false},
+		{line: "# comment", ok: false},
+		{line: "/", ok: false},
+		{line: "!", ok: false},
+		{line: "*.log", ok: true, want: Pattern{}},
+		{line: "*.log\r", ok: true, want: Pattern{}},
+		{line: "!keep.log", ok: true, want: Pattern{negate: true}},
+		{line: `\!bang`, ok: true, want: Pattern{}},
+		{line: `\#hash`, ok: true, want: Pattern{}},
+		{line: "build/", ok: true, want: Pattern{dirOnly: true}},
+		{line: "/build", ok: true, want: Pattern{anchored: true}},
+		{line: "docs/*.md", ok: true, want: Pattern{anchored: true}},
+		{line: "!/out/", ok: true, want: Pattern{negate: true, dirOnly: true, anchored: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			p, ok := ParseLine(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if p.negate != tt.want.negate || p.dirOnly != tt.want.dirOnly || p.anchored != tt.want.anchored {
+				t.Errorf("negate/dirOnly/anchored = %v/%v/%v, want %v/%v/%v",
+					p.negate, p.dirOnly, p.anchored, tt.want.negate, tt.want.dirOnly, tt.want.anchored)
+			}
+		})
+	}
+
+	// Хвостовые пробелы отбрасываются, экранированный — остаётся
+	for line, name := range map[string]string{"a.txt  ": "a.txt", `a\ `: "a ", `a\  `: "a "} {
+		p, ok := ParseLine(line)
+		if !ok || !p.re.MatchString(name) {
+			t.Errorf("%q does not match %q", line, name)
+		}
+	}
+}
+
+// level набор правил одного файла: база и строки файла
+type level struct {
+	base  string
+	rules string
+}
+
+func newMatcher(t *testing.T, levels []level) *Matcher {
+	t.Helper()
+	var m *Matcher
+	for _, l := range levels {
+		patterns, err := Parse(strings.NewReader(l.rules))
+		if err != nil {
+			t.Fatal(err)
+		}
+		m = m.Child(l.base, patterns)
+	}
+	return m
+}
+
+func TestMatcher(t *testing.T) {
+	tests := []struct {
+		name   string
+		levels []level
+		path   string
+		isDir  bool
+		want   bool
+	}{
+		{"no rules", nil, "a.log", false, false},
+		{"name at any depth", []level{{"", "*.log"}}, "a/b/c.log", false, true},
+		{"no match", []level{{"", "*.log"}}, "a/b/c.txt", false, false},
+
+		{"negation", []level{{"", "*.log\n!keep.log"}}, "keep.log", false, false},
+		{"negation keeps others", []level{{"", "*.log\n!keep.log"}}, "drop.log", false, true},
+		{"last rule wins", []level{{"", "!keep.log\n*.log"}}, "keep.log", false, true},
+
+		{"anchored at base", []level{{"", "/build"}}, "build", true, true},
+		{"anchored not deeper", []level{{"", "/build"}}, "src/build", true, false},
+		{"slash inside anchors", []level{{"", "docs/*.md"}}, "docs/a.md", false, true},
+		{"slash inside not deeper", []level{{"", "docs/*.md"}}, "x/docs/a.md", false, false},
+		{"star does not cross slash", []level{{"", "docs/*.md"}}, "docs/sub/a.md", false, false},
+
+		{"double star prefix", []level{{"", "**/tmp"}}, "a/b/tmp", true, true},
+		{"double star middle", []level{{"", "a/**/z.txt"}}, "a/b/c/z.txt", false, true},
+		{"double star suffix", []level{{"", "out/**"}}, "out/x/y", false, true},
+		{"double star suffix not dir itself", []level{{"", "out/**"}}, "out", true, false},
+
+		{"dir only matches dir", []level{{"", "cache/"}}, "a/cache", true, true},
+		{"dir only skips file", []level{{"", "cache/"}}, "a/cache", false, false},
+
+		{"nested rules apply below base", []level{{"", ""}, {"src", "*.gen"}}, "src/a/x.gen", false, true},
+		{"nested rules not outside base", []level{{"src", "*.gen"}}, "lib/x.gen", false, false},
+		{"nested base is not a prefix match", []level{{"src", "*.gen"}}, "srcx/x.gen", false, false},
+		{"nested anchored relative to base", []level{{"src", "/gen"}}, "src/gen", true, true},
+		{"nested anchored not at root", []level{{"src", "/gen"}}, "gen", true, false},
+		{"nested negation beats parent", []level{{"", "*.log"}, {"src", "!debug.log"}}, "src/debug.log", false, false},
+		{"nested ignore beats parent negation", []level{{"", "!*.tmp"}, {"src", "*.tmp"}}, "src/a.tmp", false, true},
+		{"parent applies when nested silent", []level{{"", "*.log"}, {"src", "*.tmp"}}, "src/a.log", false, true},
+		{"later file at same base wins", []level{{"", "*.log"}, {"", "!a.log"}}, "a.log", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMatcher(t, tt.levels)
+			if got := m.Match(tt.path, tt.isDir); got != tt.want {
+				t.Errorf("Match(%q, dir=%v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+// Package loc считает строки кода, комментариев и пустые строки в исходных
+// файлах, как cloc. Язык берётся из filetype (Entry.Lang).
+//
+// Разбор построчный и не знает о строковых литералах: "/*" внутри строки
+// откроет комментарий. Для обзора репозитория этой точности хватает.
+package loc
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+// Counts число строк файла или сумма по поддереву
+type Counts struct {
+	Files   int `json:"files"` // посчитанных файлов: 1 для файла, 0 — не считался
+	Code    int `json:"code"`
+	Comment int `json:"comment"`
+	Blank   int `json:"blank"`
+}
+
+// Add прибавляет счётчики другого файла или поддерева
+func (c *Counts) Add(o Counts) {
+	c.Files += o.Files
+	c.Code += o.Code
+	c.Comment += o.Comment
+	c.Blank += o.Blank
+}
+
+// Lines общее число строк
+func (c Counts) Lines() int {
+	return c.Code + c.Comment + c.Blank
+}
+
+// syntax синтаксис комментариев языка
+type syntax struct {
+	line  []string    // до конца строки: "//", "#"
+	block [][2]string // начало и конец: {"/*", "*/"}
+}
+
+var (
+	cLike  = syntax{line: []string{"//"}, block: [][2]string{{"/*", "*/"}}}
+	hash   = syntax{line: []string{"#"}}
+	markup = syntax{block: [][2]string{{"<!--", "-->"}}}
+)
+
+// languages языки, для которых считаются строки, по Entry.Lang
+var languages = map[string]syntax{
+	"c":          cLike,
+	"cpp":        cLike,
+	"csharp":     cLike,
+	"go":         cLike,
+	"groovy":     cLike,
+	"java":       cLike,
+	"javascript": cLike,
+	"kotlin":     cLike,
+	"protobuf":   cLike,
+	"rust":       cLike,
+	"scss":       cLike,
+	"swift":      cLike,
+	"typescript": cLike,
+	"gomod":      {line: []string{"//"}},
+	"css":        {block: [][2]string{{"/*", "*/"}}},
+	"php":        {line: []string{"//", "#"}, block: [][2]string{{"/*", "*/"}}},
+
+	"python":     {line: []string{"#"}, block: [][2]string{{`"""`, `"""`}, {"'''", "'''"}}},
+	"ruby":       {line: []string{"#"}, block: [][2]string{{"=begin", "=end"}}},
+	"powershell": {line: []string{"#"}, block: [][2]string{{"<#", "#>"}}},
+	"shell":      hash,
+	"fish":       hash,
+	"perl":       hash,
+	"awk":        hash,
+	"r":          hash,
+	"make":       hash,
+	"dockerfile": hash,
+	"yaml":       hash,
+	"toml":       hash,
+	"ini":        {line: []string{";", "#"}},
+
+	"lua": {line: []string{"--"}, block: [][2]string{{"--[[", "]]"}}},
+	"sql": {line: []string{"--"}, block: [][2]string{{"/*", "*/"}}},
+
+	"html":     markup,
+	"xml":      markup,
+	"markdown": markup,
+	"json":     {},
+}
+
+// Supported сообщает, умеет ли пакет считать строки языка
+func Supported(lang string) bool {
+	_, ok := languages[lang]
+	return ok
+}
+
+// Count считает строки содержимого на языке lang. Строка с кодом
+// и комментарием считается строкой кода, пустая строка внутри блочного
+// комментария — пустой.
+func Count(r io.Reader, lang string) (Counts, error) {
+	s, ok := languages[lang]
+	if !ok {
+		return Counts{}, nil
+	}
+
+	c := Counts{Files: 1}
+	br := bufio.NewReader(r)
+	var end string // конец открытого блочного комментария
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			code, comment := s.classify(line, &end)
+			switch {
+			case code:
+				c.Code++
+			case comment:
+				c.Comment++
+			default:
+				c.Blank++
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return c, nil
+		}
+		if err != nil {
+			return Counts{}, err
+		}
+	}
+}
+
+// classify сообщает, есть ли в строке код и комментарии. end хранит
+// конец блочного комментария, который продолжается на следующей строке.
+func (s syntax) classify(line string, end *string) (code, comment bool) {
+	for {
+		if *end != "" {
+			i := strings.Index(line, *end)
+			if strings.TrimSpace(line) != "" {
+				comment = true
+			}
+			if i < 0 {
+				return code, comment
+			}
+			line = line[i+len(*end):]
+			*end = ""
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return code, comment
+		}
+
+		// Ищем самый ранний маркер комментария; при равной позиции блочный
+		// важнее, чтобы "--[[" в Lua не принять за "--"
+		at, block := -1, [2]string{}
+		for _, b := range s.block {
+			if i := strings.Index(line, b[0]); i >= 0 && (at < 0 || i < at) {
+				at, block = i, b
+			}
+		}
+		for _, marker := range s.line {
+			if i := strings.Index(line, marker); i >= 0 && (at < 0 || i < at) {
+				at, block = i, [2]string{}
+			}
+		}
+		if at < 0 {
+			return true, comment
+		}
+		if at > 0 {
+			code = true
+		}
+		comment = true
+		if block[0] == "" {
+			return code, comment
+		}
+		line = line[at+len(block[0]):]
+		*end = block[1]
+	}
+}
@@ -2,45 +2,112 @@ package metrics
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/massonsky/gotree/internal/loc"
+
 	_type "github.com/massonsky/gotree/internal/types"
 )
 
 // Metrics содержит статистику по директории
 type Metrics struct {
-	TotalFiles     int
-	TotalDirs      int
-	TotalSize      int64
-	MaxDepth       int
-	ScanDuration   time.Duration
-	FilesPerSecond float64
+	TotalFiles     int           `json:"files"`
+	TotalDirs      int           `json:"directories"`
+	TotalSize      int64         `json:"total_size"`
+	MaxDepth       int           `json:"max_depth"`
+	Errors         int           `json:"errors"`     // пути, которые не удалось прочитать
+	HardLinks      int           `json:"hard_links"` // повторные жёсткие ссылки, не вошедшие в TotalSize
+	ScanDuration   time.Duration `json:"scan_duration_ns"`
+	FilesPerSecond float64       `json:"files_per_second"`
+
+	// Параллельный обход
+	Workers int     `json:"workers"`  // число воркеров, читавших директории
+	SpeedUp float64 `json:"speed_up"` // суммарное время чтения всех воркеров / время обхода
+
+	// Строки кода по языкам при --loc, по убыванию строк кода
+	Languages []Language     `json:"languages,omitempty"`
+	langIndex map[string]int // позиция языка в Languages до Finish
+}
+
+// Language итоги подсчёта строк по одному языку
+type Language struct {
+	Lang string `json:"lang"`
+	loc.Counts
 }
 
 // Collect собирает метрики из списка записей
 func Collect(entries []_type.Entry, startTime time.Time) Metrics {
 	var m Metrics
-	m.ScanDuration = time.Since(startTime)
-
 	for _, entry := range entries {
-		if entry.Info.IsDir() {
-			m.TotalDirs++
-		} else {
-			m.TotalFiles++
-			m.TotalSize += entry.Info.Size()
-		}
+		m.Add(entry, entry.Info.Size())
+	}
+	m.Finish(startTime)
+	return m
+}
 
-		if entry.Depth > m.MaxDepth {
-			m.MaxDepth = entry.Depth
-		}
+// Add учитывает одну запись. Размер передаётся отдельно, потому что
+// он зависит от режима подсчёта (длина файла или блоки на диске).
+// Позволяет собирать метрики на лету, не храня все записи.
+func (m *Metrics) Add(entry _type.Entry, size int64) {
+	if entry.Info.IsDir() {
+		m.TotalDirs++
+	} else if entry.HardLinkDup {
+		m.TotalFiles++
+		m.HardLinks++
+	} else {
+		m.TotalFiles++
+		m.TotalSize += size
+	}
+
+	if !entry.Info.IsDir() && !entry.HardLinkDup && entry.Loc.Files > 0 {
+		m.addLanguage(entry.Lang, entry.Loc)
+	}
+
+	if entry.Depth > m.MaxDepth {
+		m.MaxDepth = entry.Depth
 	}
+}
 
-	// Вычисляем производительность
+// addLanguage добавляет строки файла к итогам его языка
+func (m *Metrics) addLanguage(lang string, counts loc.Counts) {
+	if m.langIndex == nil {
+		m.langIndex = make(map[string]int)
+	}
+	i, ok := m.langIndex[lang]
+	if !ok {
+		i = len(m.Languages)
+		m.langIndex[lang] = i
+		m.Languages = append(m.Languages, Language{Lang: lang})
+	}
+	m.Languages[i].Add(counts)
+}
+
+// LinesTotal сумма строк по всем языкам
+func (m Metrics) LinesTotal() loc.Counts {
+	var total loc.Counts
+	for _, l := range m.Languages {
+		total.Add(l.Counts)
+	}
+	return total
+}
+
+// Finish фиксирует длительность и вычисляет производительность,
+// а языки упорядочивает по числу строк кода
+func (m *Metrics) Finish(startTime time.Time) {
+	sort.SliceStable(m.Languages, func(i, j int) bool {
+		if a, b := m.Languages[i].Code, m.Languages[j].Code; a != b {
+			return a > b
+		}
+		return m.Languages[i].Lang < m.Languages[j].Lang
+	})
+	m.langIndex = nil
+
+	m.ScanDuration = time.Since(startTime)
 	if m.ScanDuration.Seconds() > 0 {
 		m.FilesPerSecond = float64(m.TotalFiles+m.TotalDirs) / m.ScanDuration.Seconds()
 	}
-
-	return m
 }
 
 // String форматирует метрики для вывода
@@ -62,7 +129,7 @@ func (m Metrics) String() string {
 		perf = fmt.Sprintf("%.1f files/sec", m.FilesPerSecond)
 	}
 
-	return fmt.Sprintf(`📊 Scan Metrics:
+	out := fmt.Sprintf(`📊 Scan Metrics:
    Files:       %d
    Directories: %d
    Total Size:  %s
@@ -76,6 +143,41 @@ func (m Metrics) String() string {
 		durationStr,
 		perf,
 	)
+	if m.HardLinks > 0 {
+		out += fmt.Sprintf("\n   Hard links:  %d (counted once)", m.HardLinks)
+	}
+	if m.Errors > 0 {
+		out += fmt.Sprintf("\n   Errors:      %d", m.Errors)
+	}
+	if m.Workers > 1 {
+		out += fmt.Sprintf("\n   Workers:     %d (%.1fx speed-up)", m.Workers, m.SpeedUp)
+	}
+	if len(m.Languages) > 0 {
+		out += "\n\n📝 Languages:\n" + m.LanguagesTable()
+	}
+	return out
+}
+
+// LanguagesTable форматирует итоги по языкам таблицей, как cloc:
+// заголовок, строка на язык и итог, если языков больше одного
+func (m Metrics) LanguagesTable() string {
+	width := len("Language")
+	for _, l := range m.Languages {
+		width = max(width, len(l.Lang))
+	}
+	row := func(name string, files, code, comment, blank any) string {
+		return fmt.Sprintf("   %-*s %7v %9v %9v %9v\n", width, name, files, code, comment, blank)
+	}
+
+	out := row("Language", "Files", "Code", "Comment", "Blank")
+	for _, l := range m.Languages {
+		out += row(l.Lang, l.Files, l.Code, l.Comment, l.Blank)
+	}
+	if len(m.Languages) > 1 {
+		t := m.LinesTotal()
+		out += row("Total", t.Files, t.Code, t.Comment, t.Blank)
+	}
+	return strings.TrimSuffix(out, "\n")
 }
 
 // formatSize преобразует байты в человекочитаемый формат
@@ -4,13 +4,14 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/massonsky/gotree/internal/config"
+	"github.com/massonsky/gotree/internal/filetype"
 	"github.com/massonsky/gotree/internal/logger"
 	_metrics "github.com/massonsky/gotree/internal/metrics"
-	_type "github.com/massonsky/gotree/internal/types"
+	"github.com/massonsky/gotree/internal/tree"
 	"github.com/massonsky/gotree/internal/ui"
 
 	"github.com/fatih/color"
@@ -18,42 +19,50 @@ import (
 )
 
 // PrintTree выводит структуру директории в консоль
-func PrintTree(entries []_type.Entry, cfg *config.Config) {
-	PrintTreeToWriter(os.Stdout, entries, cfg)
+func PrintTree(root *tree.Node, cfg *config.Config) {
+	PrintTreeToWriter(os.Stdout, root, cfg)
 }
 
 // PrintTreeToWriter выводит структуру директории в указанный writer (например, stdin pager'а).
-func PrintTreeToWriter(w io.Writer, entries []_type.Entry, cfg *config.Config) {
-	logger.Debugf("Rendering tree with %d entries", len(entries))
-
-	if len(entries) == 0 {
+func PrintTreeToWriter(w io.Writer, root *tree.Node, cfg *config.Config) {
+	if root == nil {
 		color.New(color.FgRed).Fprintln(w, "No files or directories found")
 		logger.Warn("No entries to render")
 		return
 	}
+	logger.Debugf("Rendering tree with %d entries", root.FileCount+root.DirCount+1)
 
 	width, _, _ := termSize()
-	maxDepth := 0
-
-	for _, entry := range entries {
-		if entry.Depth > maxDepth {
-			maxDepth = entry.Depth
-		}
-	}
-
-	logger.Debugf("Terminal width: %d, Max depth: %d", width, maxDepth)
+	logger.Debugf("Terminal width: %d", width)
 
 	// Выводим каждый элемент
-	for i, entry := range entries {
-		isLast := (i == len(entries)-1)
-		printEntryToWriter(w, entry, isLast, width, maxDepth)
-	}
+	_ = root.Walk(func(node *tree.Node) error {
+		printEntryToWriter(w, node, width, cfg)
+		return nil
+	})
 
 	if cfg.LogLevel == "debug" {
 		color.New(color.FgYellow).Fprintln(w, "Debug mode: showing hidden files")
 		logger.Debug("Debug mode enabled")
 	}
 }
+
+// PrintStream выводит дерево построчно по мере обхода. Размеры директорий
+// в потоке неизвестны, поэтому режим --du здесь не действует.
+func PrintStream(w io.Writer, stream *tree.Stream, cfg *config.Config) error {
+	width, _, _ := termSize()
+	streamCfg := *cfg
+	streamCfg.DiskUsage = false
+
+	for node, err := range stream.Nodes() {
+		if err != nil {
+			return err
+		}
+		printEntryToWriter(w, node, width, &streamCfg)
+	}
+	return nil
+}
+
 func shouldUseColor(mode string) bool {
 	switch mode {
 	case "never":
@@ -68,34 +77,51 @@ func shouldUseColor(mode string) bool {
 }
 
 // printEntry выводит один элемент дерева с отступами
-func printEntryToWriter(w io.Writer, entry _type.Entry, isLast bool, width int, maxDepth int) {
+func printEntryToWriter(w io.Writer, node *tree.Node, width int, cfg *config.Config) {
+	line, style := formatEntry(node, width, cfg)
+
+	// Выводим с цветовым выделением
+	fmt.Fprintln(w, gitColumn(node, cfg)+style.Sprint(line))
+	// В конце функции добавляем логирование
+	logger.Tracef("Rendered entry: %s (depth: %d, size: %d)",
+		node.Path, node.Depth, node.Info.Size())
+}
+
+// formatEntry собирает строку элемента дерева и выбирает её цвет
+func formatEntry(node *tree.Node, width int, cfg *config.Config) (string, *color.Color) {
+	entry := node.Entry
+
 	// Формируем префикс для отступов
-	prefix := ""
-	if entry.Depth > 0 {
-		for d := 1; d < entry.Depth; d++ {
-			prefix += "│   "
-		}
-		if isLast {
-			prefix += "└── "
-		} else {
-			prefix += "├── "
-		}
+	prefix := node.Prefix(tree.BoxPrefixStyle)
+	if node.Omitted > 0 {
+		return prefix + node.Name(), color.New(color.Faint)
 	}
 
 	// Определяем иконку и цвет
-	icon := "📄"
-	style := color.New(color.FgWhite)
+	icon := filetype.Icon(filetype.Kind(node.Kind))
+	style := kindStyle(filetype.Kind(node.Kind))
 
-	if entry.Info.IsDir() {
+	if node.IsDir() {
 		icon = "📁"
 		style = color.New(color.FgCyan, color.Bold)
 	}
+	if node.Archive {
+		icon = "📦"
+		style = color.New(color.FgYellow, color.Bold)
+	}
+	if node.IsSymlink() {
+		icon = "🔗"
+		style = color.New(color.FgMagenta)
+		if node.Broken {
+			style = color.New(color.FgRed, color.CrossedOut)
+		}
+	}
+	if node.Err != nil {
+		style = color.New(color.FgRed)
+	}
 
 	// Обрезаем длинные имена под ширину терминала
-	displayName := filepath.Base(entry.Path)
-	if entry.Depth == 0 {
-		displayName = entry.Path
-	}
+	displayName := node.Name()
 
 	maxNameLength := width - len(prefix) - 10 // 10 для иконки и буфера
 	if len(displayName) > maxNameLength && maxNameLength > 10 {
@@ -103,19 +129,26 @@ func printEntryToWriter(w io.Writer, entry _type.Entry, isLast bool, width int,
 	}
 
 	// Формируем строку
-	line := fmt.Sprintf("%s%s %s", prefix, icon, displayName)
+	line := fmt.Sprintf("%s%s %s%s", prefix, icon, displayName, node.Suffix())
 
-	// Добавляем информацию о размере для файлов
-	if !entry.Info.IsDir() {
-		size := formatSize(entry.Info.Size())
+	// Добавляем информацию о размере для файлов (у нераскрытой ссылки размера нет),
+	// а в режиме --du — суммарный размер директорий
+	if node.IsDir() {
+		if cfg.DiskUsage {
+			line += fmt.Sprintf(" (%s, %s)", formatSize(node.Size), node.FilesLabel())
+		}
+	} else if entry.Info.Mode()&os.ModeSymlink == 0 {
+		size := formatSize(node.Size)
+		if cfg.ShowLinks && node.Links > 0 {
+			size += ", " + node.LinksLabel()
+		}
 		line += fmt.Sprintf(" (%s)", size)
 	}
-
-	// Выводим с цветовым выделением
-	style.Fprintln(w, line)
-	// В конце функции добавляем логирование
-	logger.Tracef("Rendered entry: %s (depth: %d, size: %d)",
-		entry.Path, entry.Depth, entry.Info.Size())
+	if cfg.GitHistory {
+		line += node.HistoryLabel()
+	}
+	line += node.LocLabel()
+	return line, style
 }
 
 func termSize() (int, int, error) {
@@ -171,6 +204,12 @@ func PrintMetrics(m _metrics.Metrics) {
 	fmt.Printf("   Directories: %s\n", color.BlueString("%d", m.TotalDirs))
 	fmt.Printf("   Total Size:  %s\n", color.YellowString("%s", _metrics.FormatSize(m.TotalSize)))
 	fmt.Printf("   Max Depth:   %s\n", color.MagentaString("%d", m.MaxDepth))
+	if m.HardLinks > 0 {
+		fmt.Printf("   Hard links:  %s\n", color.YellowString("%d (counted once)", m.HardLinks))
+	}
+	if m.Errors > 0 {
+		fmt.Printf("   Errors:      %s\n", color.RedString("%d", m.Errors))
+	}
 	// форматируем длительность с большей точностью для очень коротких измерений
 	var durationStr string
 	if m.ScanDuration < time.Millisecond {
@@ -186,4 +225,70 @@ func PrintMetrics(m _metrics.Metrics) {
 	} else if m.FilesPerSecond > 0 {
 		fmt.Printf("   Performance: %s\n", color.CyanString("%.1f files/sec", m.FilesPerSecond))
 	}
+
+	if m.Workers > 1 {
+		fmt.Printf("   Workers:     %s\n", color.CyanString("%d (%.1fx speed-up)", m.Workers, m.SpeedUp))
+	}
+
+	if len(m.Languages) > 0 {
+		fmt.Println()
+		fmt.Println(color.New(color.FgHiCyan, color.Bold).Sprint("📝 Languages"))
+		table := strings.Split(m.LanguagesTable(), "\n")
+		fmt.Println(color.New(color.Bold).Sprint(table[0]))
+		for _, row := range table[1:] {
+			fmt.Println(row)
+		}
+	}
+}
+
+// kindStyle цвет файла по его виду, как в раскраске ls
+func kindStyle(kind filetype.Kind) *color.Color {
+	switch kind {
+	case filetype.Executable:
+		return color.New(color.FgGreen, color.Bold)
+	case filetype.Script:
+		return color.New(color.FgGreen)
+	case filetype.Archive:
+		return color.New(color.FgRed)
+	case filetype.Image, filetype.Video:
+		return color.New(color.FgHiMagenta)
+	case filetype.Audio:
+		return color.New(color.FgCyan)
+	case filetype.Binary, filetype.Font:
+		return color.New(color.FgHiBlack)
+	default:
+		return color.New(color.FgWhite)
+	}
+}
+
+// gitColors цвета букв состояния git, как у eza --git
+var gitColors = map[byte]*color.Color{
+	'N': color.New(color.FgGreen),
+	'M': color.New(color.FgBlue),
+	'D': color.New(color.FgRed),
+	'R': color.New(color.FgYellow),
+	'T': color.New(color.FgMagenta),
+	'U': color.New(color.FgRed, color.Bold),
+	'I': color.New(color.FgHiBlack),
+	'-': color.New(color.FgHiBlack),
+}
+
+// gitColumn возвращает колонку состояния git перед строкой дерева.
+// Вне репозитория колонки нет, записи внутри архивов получают пустую.
+func gitColumn(node *tree.Node, cfg *config.Config) string {
+	if !cfg.GitStatus && !cfg.GitChangedOnly || node.Root().Git == "" {
+		return ""
+	}
+	if node.Git == "" {
+		return "   "
+	}
+	var b strings.Builder
+	for i := 0; i < len(node.Git); i++ {
+		style, ok := gitColors[node.Git[i]]
+		if !ok {
+			style = color.New(color.FgWhite)
+		}
+		b.WriteString(style.Sprint(node.Git[i : i+1]))
+	}
+	return b.String() + " "
 }
@@ -0,0 +1,66 @@
+package renderer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/massonsky/gotree/internal/config"
+	"github.com/massonsky/gotree/internal/tree"
+
+	"github.com/fatih/color"
+)
+
+// hashLabelLen сколько символов хэша показывать в сводке
+const hashLabelLen = 12
+
+// PrintDuplicates выводит дерево, в котором остались только дубликаты
+// с номером группы у каждого файла, и сводку по группам
+func PrintDuplicates(w io.Writer, dupRoot *tree.Node, groups []tree.DupGroup, cfg *config.Config) {
+	if len(groups) == 0 {
+		color.New(color.FgGreen).Fprintln(w, "No duplicate files found")
+		return
+	}
+
+	// Копии в dupRoot — другие узлы, поэтому группы ищем по пути
+	groupOf := make(map[string]int)
+	for i, g := range groups {
+		for _, node := range g.Nodes {
+			groupOf[node.Path] = i + 1
+		}
+	}
+
+	width, _, _ := termSize()
+	_ = dupRoot.Walk(func(node *tree.Node) error {
+		line, style := formatEntry(node, width, cfg)
+		if n, ok := groupOf[node.Path]; ok && !node.IsDir() {
+			line += color.New(color.FgHiMagenta).Sprintf(" [#%d]", n)
+		}
+		fmt.Fprintln(w, gitColumn(node, cfg)+style.Sprint(line))
+		return nil
+	})
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, color.New(color.FgHiCyan, color.Bold).Sprint("🔁 Duplicates"))
+	var files int
+	var total int64
+	for i, g := range groups {
+		files += len(g.Nodes) - 1
+		total += g.Reclaimable()
+		fmt.Fprintf(w, "   #%d  %d × %s, reclaimable %s  %s\n",
+			i+1, len(g.Nodes), formatSize(g.Size),
+			color.YellowString("%s", formatSize(g.Reclaimable())),
+			color.HiBlackString("%s", shortHash(g.Hash)))
+	}
+	fmt.Fprintf(w, "   Total:  %d group(s), %d redundant file(s), %s reclaimable\n",
+		len(groups), files, color.YellowString("%s", formatSize(total)))
+}
+
+// shortHash сокращает "sha256:hex" до первых символов хэша
+func shortHash(hash string) string {
+	algo, sum, ok := strings.Cut(hash, ":")
+	if !ok || len(sum) <= hashLabelLen {
+		return hash
+	}
+	return algo + ":" + sum[:hashLabelLen]
+}
@@ -0,0 +1,75 @@
+package tree
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+// Операции, на которых может споткнуться обход
+const (
+	OpReadDir  = "readdir"
+	OpLstat    = "lstat"
+	OpReadlink = "readlink"
+	OpArchive  = "archive"
+	OpHash     = "hash"
+	OpLoc      = "loc"
+)
+
+// ScanError ошибка чтения одного пути. Обход при этом продолжается,
+// если не включён режим --strict.
+type ScanError struct {
+	Path string // путь относительно корня обхода, как в types.Entry
+	Op   string // одна из констант Op*
+	Err  error  // исходная причина, например syscall.EACCES
+}
+
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Op, e.Path, e.Err)
+}
+
+func (e *ScanError) Unwrap() error {
+	return e.Err
+}
+
+// Label возвращает пометку для вывода рядом с именем, как в GNU tree
+func (e *ScanError) Label() string {
+	switch e.Op {
+	case OpReadDir:
+		return "[error opening dir]"
+	case OpLstat:
+		return "[error reading info]"
+	case OpReadlink:
+		return "[error reading link]"
+	case OpArchive:
+		return "[error opening archive]"
+	case OpHash, OpLoc:
+		return "[error reading content]"
+	default:
+		return "[error]"
+	}
+}
+
+// newScanError снимает с ошибки обёртку *fs.PathError: путь и операция
+// и так хранятся в ScanError
+func newScanError(path, op string, err error) *ScanError {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+	return &ScanError{Path: path, Op: op, Err: err}
+}
+
+// placeholderInfo подменяет os.FileInfo, когда lstat не удался:
+// тип берётся из записи директории, остальное неизвестно
+type placeholderInfo struct {
+	d fs.DirEntry
+}
+
+func (p placeholderInfo) Name() string       { return p.d.Name() }
+func (p placeholderInfo) Size() int64        { return 0 }
+func (p placeholderInfo) Mode() fs.FileMode  { return p.d.Type() }
+func (p placeholderInfo) ModTime() time.Time { return time.Time{} }
+func (p placeholderInfo) IsDir() bool        { return p.d.IsDir() }
+func (p placeholderInfo) Sys() any           { return nil }
@@ -0,0 +1,44 @@
+package tree
+
+import (
+	"io"
+	"path"
+	"path/filepath"
+
+	"github.com/massonsky/gotree/internal/filetype"
+	"github.com/massonsky/gotree/internal/logger"
+	"github.com/massonsky/gotree/internal/types"
+)
+
+// detectType определяет тип обычного файла: по имени всегда, по первым
+// байтам содержимого — при --sniff. У loc без fsys содержимое недоступно.
+func (w *walker) detectType(e *types.Entry, loc location) {
+	if e.Info == nil || !e.Info.Mode().IsRegular() {
+		return
+	}
+	var head []byte
+	if w.cfg.SniffContent && loc.fsys != nil {
+		head = readHead(loc)
+	}
+	t := filetype.Detect(path.Base(filepath.ToSlash(e.Path)), e.Info.Mode(), head)
+	e.Kind, e.MIME, e.Lang = string(t.Kind), t.MIME, t.Lang
+}
+
+// readHead читает начало файла для определения типа; nil — файл
+// не удалось прочитать, тогда тип определяется по имени
+func readHead(loc location) []byte {
+	f, err := loc.fsys.Open(loc.name)
+	if err != nil {
+		logger.Debugf("Cannot sniff %s: %v", loc.name, err)
+		return nil
+	}
+	defer f.Close()
+
+	head := make([]byte, filetype.SniffLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		logger.Debugf("Cannot sniff %s: %v", loc.name, err)
+		return nil
+	}
+	return head[:n]
+}
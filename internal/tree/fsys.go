@@ -0,0 +1,133 @@
+package tree
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// readLinkFS файловая система, умеющая читать цели символических ссылок
+type readLinkFS interface {
+	fs.FS
+	ReadLink(name string) (string, error)
+}
+
+// osFS файловая система диска с корнем в dir. В отличие от os.DirFS
+// умеет читать ссылки, а ReadDir отдаёт сведения lstat, как os.ReadDir.
+type osFS struct {
+	dir string
+}
+
+func (f osFS) join(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return filepath.Join(f.dir, filepath.FromSlash(name)), nil
+}
+
+func (f osFS) Open(name string) (fs.File, error) {
+	full, err := f.join("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(full)
+}
+
+func (f osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	full, err := f.join("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadDir(full)
+}
+
+func (f osFS) Stat(name string) (fs.FileInfo, error) {
+	full, err := f.join("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(full)
+}
+
+func (f osFS) ReadLink(name string) (string, error) {
+	full, err := f.join("readlink", name)
+	if err != nil {
+		return "", err
+	}
+	return os.Readlink(full)
+}
+
+// location место записи: файловая система и путь внутри неё
+type location struct {
+	fsys fs.FS
+	name string
+}
+
+func (l location) join(name string) location {
+	return location{fsys: l.fsys, name: path.Join(l.name, name)}
+}
+
+// diskPath возвращает путь на диске, если запись лежит не внутри архива
+func (l location) diskPath() (string, bool) {
+	f, ok := l.fsys.(osFS)
+	if !ok {
+		return "", false
+	}
+	return filepath.Join(f.dir, filepath.FromSlash(l.name)), true
+}
+
+// realName путь внутри fsys без символических ссылок, как filepath.EvalSymlinks.
+// Файловые системы без ReadLink возвращают путь как есть.
+func realName(fsys fs.FS, name string) string {
+	rl, ok := fsys.(readLinkFS)
+	if !ok {
+		return path.Clean(name)
+	}
+
+	const maxHops = 40
+	resolved, hops := ".", 0
+	rest := strings.Split(path.Clean(name), "/")
+	for len(rest) > 0 {
+		part := rest[0]
+		rest = rest[1:]
+		switch part {
+		case ".", "":
+			continue
+		case "..":
+			resolved = path.Dir(resolved)
+			continue
+		}
+
+		next := path.Join(resolved, part)
+		target, err := rl.ReadLink(next)
+		if err != nil || hops == maxHops {
+			resolved = next // не ссылка
+			continue
+		}
+		hops++
+		if path.IsAbs(target) {
+			resolved = "."
+		}
+		rest = append(strings.Split(strings.TrimPrefix(target, "/"), "/"), rest...)
+	}
+	return resolved
+}
+
+// readLink читает цель ссылки. Файловые системы без ReadLink (zip,
+// fstest.MapFS) хранят цель как содержимое файла.
+func (l location) readLink() (string, error) {
+	if rl, ok := l.fsys.(readLinkFS); ok {
+		return rl.ReadLink(l.name)
+	}
+	f, err := l.fsys.Open(l.name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	target, err := io.ReadAll(io.LimitReader(f, 4096))
+	return string(target), err
+}
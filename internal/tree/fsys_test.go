@@ -0,0 +1,309 @@
+package tree
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/massonsky/gotree/internal/archive"
+	"github.com/massonsky/gotree/internal/config"
+)
+
+// mapFSLinks сообщает, понимает ли fstest.MapFS символические ссылки (Go 1.25+)
+func mapFSLinks() bool {
+	_, ok := any(fstest.MapFS{}).(readLinkFS)
+	return ok
+}
+
+func symlink(target string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(target), Mode: fs.ModeSymlink | 0o777}
+}
+
+func TestWalkFS(t *testing.T) {
+	project := fstest.MapFS{
+		"root/README.md":           {Data: []byte("readme")},
+		"root/.env":                {Data: []byte("SECRET=1")},
+		"root/src/main.go":         {Data: []byte("package main")},
+		"root/src/util/strings.go": {Data: []byte("package util")},
+		"root/.hidden/config":      {Data: []byte("x")},
+	}
+
+	tests := []struct {
+		name      string
+		fsys      fs.FS
+		cfg       config.Config
+		needLinks bool
+		want      []string
+	}{
+		{
+			name: "defaults hide dotfiles",
+			fsys: project,
+			want: []string{
+				"root",
+				"├── README.md",
+				"└── src",
+				"    ├── main.go",
+				"    └── util",
+				"        └── strings.go",
+			},
+		},
+		{
+			name: "hidden files",
+			fsys: project,
+			cfg:  config.Config{ShowHiddenFiles: true},
+			want: []string{
+				"root",
+				"├── .env",
+				"├── .hidden",
+				"│   └── config",
+				"├── README.md",
+				"└── src",
+				"    ├── main.go",
+				"    └── util",
+				"        └── strings.go",
+			},
+		},
+		{
+			name: "depth",
+			fsys: project,
+			cfg:  config.Config{MaxDepth: 2},
+			want: []string{
+				"root",
+				"├── README.md",
+				"└── src",
+				"    ├── main.go",
+				"    └── util",
+			},
+		},
+		{
+			name: "gitignore",
+			fsys: fstest.MapFS{
+				"root/.gitignore":        {Data: []byte("*.log\n/build/\n!keep.log\n")},
+				"root/app.log":           {Data: []byte("x")},
+				"root/keep.log":          {Data: []byte("x")},
+				"root/build/out":         {Data: []byte("x")},
+				"root/src/build/gen.go":  {Data: []byte("x")},
+				"root/src/.gitignore":    {Data: []byte("gen.go\n")},
+				"root/src/trace.log":     {Data: []byte("x")},
+				"root/.git/HEAD":         {Data: []byte("ref: refs/heads/main")},
+				"root/.git/info/exclude": {Data: []byte("keep.log\n")},
+			},
+			cfg: config.Config{GitIgnore: true, ShowHiddenFiles: true},
+			want: []string{
+				"root",
+				"├── .gitignore",
+				"├── keep.log", // .gitignore сильнее .git/info/exclude
+				"└── src",
+				"    ├── .gitignore",
+				"    └── build",
+			},
+		},
+		{
+			name:      "symlinks",
+			needLinks: true,
+			fsys: fstest.MapFS{
+				"root/data/file.txt": {Data: []byte("x")},
+				"root/broken":        symlink("missing"),
+				"root/data-link":     symlink("data"),
+				"root/file-link":     symlink("data/file.txt"),
+				"root/loop/self":     symlink(".."),
+			},
+			want: []string{
+				"root",
+				"├── broken -> missing  [broken]",
+				"├── data",
+				"│   └── file.txt",
+				"├── data-link -> data",
+				"├── file-link -> data/file.txt",
+				"└── loop",
+				"    └── self -> ..",
+			},
+		},
+		{
+			name:      "follow symlinks",
+			needLinks: true,
+			fsys: fstest.MapFS{
+				"root/data/file.txt": {Data: []byte("x")},
+				"root/data-link":     symlink("data"),
+				"root/loop/self":     symlink(".."),
+			},
+			cfg: config.Config{FollowSymlinks: true},
+			want: []string{
+				"root",
+				"├── data",
+				"│   └── file.txt",
+				"├── data-link -> data",
+				"│   └── file.txt",
+				"└── loop",
+				"    └── self -> ..  [recursive, not followed]",
+			},
+		},
+		{
+			name: "unreadable directory",
+			fsys: errFS{
+				MapFS: fstest.MapFS{
+					"root/ok/a":     {Data: []byte("a")},
+					"root/locked/b": {Data: []byte("b")},
+				},
+				denied: map[string]bool{"root/locked": true},
+			},
+			want: []string{
+				"root",
+				"├── locked  [error opening dir]",
+				"└── ok",
+				"    └── a",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.needLinks && !mapFSLinks() {
+				t.Skip("fstest.MapFS without symlink support")
+			}
+			result, err := WalkFS(context.Background(), tt.fsys, "root", &tt.cfg)
+			if err != nil {
+				t.Fatalf("WalkFS: %v", err)
+			}
+			if got := treeLines(result.Root); !slices.Equal(got, tt.want) {
+				t.Errorf("lines:\n%q\nwant:\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWalkFSErrors(t *testing.T) {
+	fsys := errFS{
+		MapFS: fstest.MapFS{
+			"root/a/x":   {Data: []byte("x")},
+			"root/b/c/y": {Data: []byte("y")},
+		},
+		denied: map[string]bool{"root/a": true, "root/b/c": true},
+	}
+
+	result, err := WalkFS(context.Background(), fsys, "root", &config.Config{})
+	if err != nil {
+		t.Fatalf("WalkFS: %v", err)
+	}
+	var paths []string
+	for _, scanErr := range result.Errors {
+		if scanErr.Op != OpReadDir {
+			t.Errorf("%s: op %q, want %q", scanErr.Path, scanErr.Op, OpReadDir)
+		}
+		paths = append(paths, scanErr.Path)
+	}
+	if want := []string{"a", "b/c"}; !slices.Equal(paths, want) {
+		t.Errorf("error paths = %q, want %q", paths, want)
+	}
+	if result.Metrics.Errors != 2 {
+		t.Errorf("metrics errors = %d, want 2", result.Metrics.Errors)
+	}
+
+	_, err = WalkFS(context.Background(), fsys, "root", &config.Config{Strict: true})
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("strict walk error = %v, want permission error", err)
+	}
+}
+
+func TestWalkFSArchiveRoot(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"app/main.go":      "package main",
+		"app/lib/util.go":  "package lib",
+		"app/README.md":    "readme",
+		"app/.hidden/skip": "x",
+	}
+	writeZip(t, filepath.Join(dir, "release.zip"), files)
+	writeTar(t, filepath.Join(dir, "release.tar"), files)
+
+	want := []string{
+		".",
+		"└── app",
+		"    ├── README.md",
+		"    ├── lib",
+		"    │   └── util.go",
+		"    └── main.go",
+	}
+	for _, name := range []string{"release.zip", "release.tar"} {
+		t.Run(name, func(t *testing.T) {
+			fsys, closer, err := archive.Open(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatalf("archive.Open: %v", err)
+			}
+			defer closer.Close()
+
+			result, err := WalkFS(context.Background(), fsys, ".", &config.Config{})
+			if err != nil {
+				t.Fatalf("WalkFS: %v", err)
+			}
+			if got := treeLines(result.Root); !slices.Equal(got, want) {
+				t.Errorf("lines:\n%q\nwant:\n%q", got, want)
+			}
+			if result.Metrics.TotalFiles != 3 {
+				t.Errorf("files = %d, want 3", result.Metrics.TotalFiles)
+			}
+		})
+	}
+}
+
+func writeZip(t *testing.T, filename string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, name := range sortedKeys(files) {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTar(t *testing.T, filename string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tw := tar.NewWriter(f)
+	for _, name := range sortedKeys(files) {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(files[name])), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
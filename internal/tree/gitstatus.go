@@ -0,0 +1,128 @@
+package tree
+
+import (
+	"errors"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/massonsky/gotree/internal/gitstatus"
+	"github.com/massonsky/gotree/internal/logger"
+	"github.com/massonsky/gotree/internal/types"
+)
+
+// markGitStatus записывает в узлы состояние git (--git-status), а при
+// --git-changed-only помечает неизменённые узлы для prune. Записи вне
+// репозитория и внутри архивов остаются без состояния.
+func (w *walker) markGitStatus(root *Node) {
+	dir, ok := gitDir(root)
+	if !ok {
+		return
+	}
+	repo, err := gitstatus.Load(w.ctx, dir)
+	if err != nil {
+		logGitError(dir, err)
+		return
+	}
+	if rel, ok := repoRel(root, dir, repo.Root); ok {
+		root.applyGitStatus(repo, rel, w.cfg.GitChangedOnly)
+	}
+}
+
+// needsGitHistory сообщает, нужна ли история git: для колонок
+// --git-history, сортировки или --where по её полям
+func (w *walker) needsGitHistory() bool {
+	if w.cfg.GitHistory {
+		return true
+	}
+	if mode, err := ParseSortMode(w.cfg.SortBy); err == nil && mode.usesGitHistory() {
+		return true
+	}
+	return w.where != nil && w.where.Uses("commits", "committed", "author")
+}
+
+// loadGitHistory читает историю поддерева одним git log до обхода,
+// чтобы по её полям работали --where и сортировка. Записи получают
+// свою часть истории в readDir (см. addHistory).
+func (w *walker) loadGitHistory(root *Node) {
+	dir, ok := gitDir(root)
+	if !ok {
+		return
+	}
+	history, err := gitstatus.LoadHistory(w.ctx, dir)
+	if err != nil {
+		logGitError(dir, err)
+		return
+	}
+	rel, ok := repoRel(root, dir, history.Root)
+	if !ok {
+		return
+	}
+	w.history = history
+	w.historyPrefix = rel
+	w.addHistory(&root.Entry, rel)
+}
+
+// addHistory заполняет поля истории записи по пути от корня репозитория
+func (w *walker) addHistory(e *types.Entry, rel string) {
+	if fh, ok := w.history.Lookup(rel); ok {
+		e.LastCommit, e.LastAuthor, e.Commits = fh.LastCommit, fh.LastAuthor, fh.Commits
+	}
+}
+
+// gitDir возвращает директорию на диске, в которой запускается git
+func gitDir(root *Node) (string, bool) {
+	dir, ok := root.src.diskPath()
+	if !ok {
+		return "", false
+	}
+	if !root.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	return dir, true
+}
+
+// repoRel возвращает путь корня обхода от корня репозитория со слешами
+func repoRel(root *Node, dir, repoRoot string) (string, bool) {
+	// git сообщает пути от корня без символических ссылок
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = real
+	}
+	rel, err := filepath.Rel(repoRoot, dir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		logger.Warnf("Cannot place %s inside repository %s", dir, repoRoot)
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	if !root.IsDir() {
+		rel = path.Join(rel, root.Name())
+	}
+	return rel, true
+}
+
+func logGitError(dir string, err error) {
+	if errors.Is(err, gitstatus.ErrNotRepository) {
+		logger.Debugf("%s is not inside a git repository", dir)
+		return
+	}
+	logger.Warnf("Git data unavailable: %v", err)
+}
+
+// applyGitStatus проставляет состояние узлу и его поддереву. Состояние
+// директории сводится из детей, если она не игнорируется целиком.
+func (n *Node) applyGitStatus(repo *gitstatus.Repo, rel string, changedOnly bool) {
+	if _, onDisk := n.src.diskPath(); !onDisk {
+		return
+	}
+
+	n.Git = repo.Status(rel)
+	for _, child := range n.Children {
+		child.applyGitStatus(repo, path.Join(rel, child.Name()), changedOnly)
+		if n.IsDir() && n.Git != gitstatus.Ignored {
+			n.Git = gitstatus.Merge(n.Git, child.Git)
+		}
+	}
+	if changedOnly && !gitstatus.Changed(n.Git) {
+		n.ancestorOnly = true
+	}
+}
@@ -0,0 +1,306 @@
+package tree
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/massonsky/gotree/internal/config"
+	"github.com/massonsky/gotree/internal/logger"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// HashAlgo алгоритм хэширования содержимого файлов
+type HashAlgo string
+
+const (
+	HashSHA256 HashAlgo = "sha256"
+	HashXXH64  HashAlgo = "xxhash" // xxh64: намного быстрее, но не криптографический
+)
+
+// ParseHashAlgo проверяет имя алгоритма. Пустая строка означает HashSHA256.
+func ParseHashAlgo(s string) (HashAlgo, error) {
+	switch strings.ToLower(s) {
+	case "", string(HashSHA256):
+		return HashSHA256, nil
+	case string(HashXXH64), "xxh64":
+		return HashXXH64, nil
+	default:
+		return "", fmt.Errorf("unknown hash algorithm %q (supported: %s, %s)", s, HashSHA256, HashXXH64)
+	}
+}
+
+func (a HashAlgo) new() hash.Hash {
+	if a == HashXXH64 {
+		return xxhash.New()
+	}
+	return sha256.New()
+}
+
+// partialHashSize сколько байт с начала файла сравнивается до полного хэша
+const partialHashSize = 4096
+
+// DupGroup группа файлов с одинаковым содержимым
+type DupGroup struct {
+	Hash  string  // полный хэш, как в Entry.Hash
+	Size  int64   // размер одной копии
+	Nodes []*Node // копии в порядке вывода
+}
+
+// Reclaimable сколько байт освободится, если оставить одну копию
+func (g DupGroup) Reclaimable() int64 {
+	return g.Size * int64(len(g.Nodes)-1)
+}
+
+// HashFiles считает хэш содержимого всех обычных файлов дерева
+// и записывает его в Entry.Hash. Файл, который не удалось прочитать,
+// получает ошибку OpHash; эти ошибки возвращаются в порядке вывода.
+func HashFiles(ctx context.Context, root *Node, cfg *config.Config) ([]ScanError, error) {
+	files := hashableFiles(root)
+	logger.Debugf("Hashing %d file(s)", len(files))
+	_, scanErrs, err := hashNodes(ctx, files, hashAlgo(cfg), -1, hashJobs(cfg))
+	return scanErrs, err
+}
+
+// FindDuplicates ищет файлы с одинаковым содержимым. Файлы сначала
+// группируются по размеру, затем кандидаты сравниваются по хэшу первых
+// 4 КБ и только потом по хэшу всего содержимого, который записывается
+// в Entry.Hash. Пустые файлы, символические ссылки и повторные жёсткие
+// ссылки дубликатами не считаются. Группы упорядочены по убыванию
+// освобождаемого места.
+func FindDuplicates(ctx context.Context, root *Node, cfg *config.Config) ([]DupGroup, error) {
+	algo, jobs := hashAlgo(cfg), hashJobs(cfg)
+
+	bySize := make(map[int64][]*Node)
+	for _, node := range hashableFiles(root) {
+		if node.IsSymlink() || node.HardLinkDup || node.Info.Size() == 0 {
+			continue
+		}
+		bySize[node.Info.Size()] = append(bySize[node.Info.Size()], node)
+	}
+	var candidates [][]*Node
+	for _, nodes := range bySize {
+		if len(nodes) > 1 {
+			candidates = append(candidates, nodes)
+		}
+	}
+
+	candidates, err := regroup(ctx, candidates, algo, partialHashSize, jobs)
+	if err != nil {
+		return nil, err
+	}
+	// Короткие файлы уже прочитаны целиком, остальные дочитываем
+	var done, rest [][]*Node
+	for _, nodes := range candidates {
+		if nodes[0].Info.Size() <= partialHashSize {
+			done = append(done, nodes)
+		} else {
+			rest = append(rest, nodes)
+		}
+	}
+	rest, err = regroup(ctx, rest, algo, -1, jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]DupGroup, 0, len(done)+len(rest))
+	for _, nodes := range append(done, rest...) {
+		groups = append(groups, DupGroup{Hash: nodes[0].Hash, Size: nodes[0].Size, Nodes: nodes})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if a, b := groups[i].Reclaimable(), groups[j].Reclaimable(); a != b {
+			return a > b
+		}
+		return groups[i].Nodes[0].Path < groups[j].Nodes[0].Path
+	})
+	return groups, nil
+}
+
+// DuplicateTree возвращает копию дерева, в которой остались только
+// файлы из групп и ведущие к ним директории, с пересчитанными размерами.
+// --max-children применяется к уже отобранному дереву.
+func DuplicateTree(root *Node, groups []DupGroup, cfg *config.Config) *Node {
+	keep := make(map[*Node]bool)
+	for _, g := range groups {
+		for _, node := range g.Nodes {
+			keep[node] = true
+		}
+	}
+	dupRoot := root.selectCopy(keep, nil)
+	if dupRoot != nil {
+		dupRoot.Aggregate(sizeMode(cfg))
+		if cfg.MaxChildren > 0 {
+			dupRoot.limitChildren(cfg.MaxChildren)
+		}
+	}
+	return dupRoot
+}
+
+// selectCopy копирует узлы из keep вместе с их предками
+func (n *Node) selectCopy(keep map[*Node]bool, parent *Node) *Node {
+	c := &Node{Entry: n.Entry, src: n.src}
+	for _, child := range n.Children {
+		child.selectCopy(keep, c)
+	}
+	if len(c.Children) == 0 && !keep[n] && parent != nil {
+		return nil
+	}
+	if parent != nil {
+		parent.AddChild(c)
+	}
+	return c
+}
+
+// regroup хэширует первые limit байт файлов (limit < 0 — целиком) и делит
+// каждую группу по хэшу; группы из одного файла отбрасываются. Хэш,
+// покрывший файл целиком, записывается в Entry.Hash.
+func regroup(ctx context.Context, groups [][]*Node, algo HashAlgo, limit int64, jobs int) ([][]*Node, error) {
+	var nodes []*Node
+	for _, g := range groups {
+		nodes = append(nodes, g...)
+	}
+	sums, _, err := hashNodes(ctx, nodes, algo, limit, jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	var out [][]*Node
+	i := 0
+	for _, g := range groups {
+		var order []string
+		bySum := make(map[string][]*Node)
+		for _, node := range g {
+			sum := sums[i]
+			i++
+			if sum == "" {
+				continue
+			}
+			if _, ok := bySum[sum]; !ok {
+				order = append(order, sum)
+			}
+			bySum[sum] = append(bySum[sum], node)
+		}
+		for _, sum := range order {
+			if len(bySum[sum]) > 1 {
+				out = append(out, bySum[sum])
+			}
+		}
+	}
+	return out, nil
+}
+
+// hashNodes хэширует файлы в jobs горутин и возвращает хэши в том же
+// порядке; для файла с ошибкой чтения — "", а сама ошибка попадает
+// в список OpHash. Каждый узел обрабатывает ровно одна горутина,
+// поэтому Hash и Err пишутся без блокировок.
+func hashNodes(ctx context.Context, nodes []*Node, algo HashAlgo, limit int64, jobs int) ([]string, []ScanError, error) {
+	sums := make([]string, len(nodes))
+	failed := make([]*ScanError, len(nodes))
+	next := make(chan int)
+
+	var wg sync.WaitGroup
+	for range min(jobs, len(nodes)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				node := nodes[i]
+				sum, err := hashFile(node, algo, limit)
+				if err != nil {
+					logger.Warnf("Hash error: %v", err)
+					failed[i] = newScanError(node.Path, OpHash, err)
+					if node.Err == nil {
+						node.Err = failed[i]
+					}
+					continue
+				}
+				sums[i] = sum
+				if limit < 0 || node.Info.Size() <= limit {
+					node.Hash = sum
+				}
+			}
+		}()
+	}
+
+	var err error
+feed:
+	for i := range nodes {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			err = ctx.Err()
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	var scanErrs []ScanError
+	for _, scanErr := range failed {
+		if scanErr != nil {
+			scanErrs = append(scanErrs, *scanErr)
+		}
+	}
+	return sums, scanErrs, err
+}
+
+// hashFile хэширует первые limit байт файла (limit < 0 — весь файл)
+// и возвращает хэш в виде "алгоритм:hex"
+func hashFile(node *Node, algo HashAlgo, limit int64) (string, error) {
+	f, err := node.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if limit >= 0 {
+		r = io.LimitReader(f, limit)
+	}
+	h := algo.new()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", &fs.PathError{Op: "read", Path: node.Path, Err: err}
+	}
+	return string(algo) + ":" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashableFiles возвращает обычные файлы дерева, содержимое которых
+// можно прочитать (в дереве из списка путей его нет)
+func hashableFiles(root *Node) []*Node {
+	var files []*Node
+	_ = root.Walk(func(node *Node) error {
+		if node.Info != nil && node.Info.Mode().IsRegular() && node.src.fsys != nil && node.Err == nil {
+			files = append(files, node)
+		}
+		return nil
+	})
+	return files
+}
+
+// hashAlgo читает алгоритм хэширования из конфига
+func hashAlgo(cfg *config.Config) HashAlgo {
+	algo, err := ParseHashAlgo(cfg.HashAlgo)
+	if err != nil {
+		logger.Warnf("%v, falling back to %s", err, HashSHA256)
+		algo = HashSHA256
+	}
+	return algo
+}
+
+// hashJobs возвращает число горутин хэширования: --jobs, а если обход
+// последовательный — по числу процессоров
+func hashJobs(cfg *config.Config) int {
+	if cfg.Jobs > 1 {
+		return cfg.Jobs
+	}
+	return runtime.GOMAXPROCS(0)
+}
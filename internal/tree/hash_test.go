@@ -0,0 +1,63 @@
+package tree
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/massonsky/gotree/internal/config"
+)
+
+// unreadableFS файловая система, в которой содержимое перечисленных
+// файлов не открывается, хотя сами записи видны в директории
+type unreadableFS struct {
+	fstest.MapFS
+	denied map[string]bool
+}
+
+func (f unreadableFS) Open(name string) (fs.File, error) {
+	if f.denied[name] {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return f.MapFS.Open(name)
+}
+
+func TestHashErrors(t *testing.T) {
+	fsys := unreadableFS{
+		MapFS: fstest.MapFS{
+			"root/a.txt":     {Data: []byte("a")},
+			"root/sub/b.txt": {Data: []byte("b")},
+			"root/sub/c.txt": {Data: []byte("c")},
+		},
+		denied: map[string]bool{"root/sub/b.txt": true},
+	}
+
+	result, err := WalkFS(context.Background(), fsys, "root", &config.Config{Hash: true})
+	if err != nil {
+		t.Fatalf("WalkFS: %v", err)
+	}
+	want := []ScanError{{Path: "sub/b.txt", Op: OpHash, Err: fs.ErrPermission}}
+	if !slices.Equal(result.Errors, want) {
+		t.Errorf("errors = %v, want %v", result.Errors, want)
+	}
+	if result.Metrics.Errors != 1 {
+		t.Errorf("metrics errors = %d, want 1", result.Metrics.Errors)
+	}
+	for _, node := range result.Root.Children[1].Children {
+		if (node.Hash == "") != (node.Name() == "b.txt") {
+			t.Errorf("%s: hash %q", node.Path, node.Hash)
+		}
+	}
+	if got := result.Root.Children[1].Children[0].ErrorLabel(); got != "  [error reading content]" {
+		t.Errorf("label = %q", got)
+	}
+
+	_, err = WalkFS(context.Background(), fsys, "root", &config.Config{Hash: true, Strict: true})
+	var scanErr *ScanError
+	if !errors.As(err, &scanErr) || scanErr.Op != OpHash {
+		t.Errorf("strict walk error = %v, want hash error", err)
+	}
+}
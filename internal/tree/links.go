@@ -0,0 +1,49 @@
+package tree
+
+import "github.com/massonsky/gotree/internal/types"
+
+// fileKey идентифицирует файл на диске независимо от пути
+type fileKey struct {
+	dev, ino uint64
+}
+
+// linkSet запоминает файлы с несколькими жёсткими ссылками, чтобы
+// учесть каждый в размерах один раз. Первой считается ссылка, которая
+// идёт раньше в порядке вывода; остальные помечаются HardLinkDup.
+type linkSet map[fileKey]struct{}
+
+func (s linkSet) visit(e *types.Entry) {
+	if e.Info == nil || e.Info.IsDir() {
+		return
+	}
+	key, nlink, ok := linkInfo(e.Info)
+	if !ok {
+		return
+	}
+	e.Links = int(nlink)
+	if nlink < 2 {
+		return
+	}
+	if _, seen := s[key]; seen {
+		e.HardLinkDup = true
+		return
+	}
+	s[key] = struct{}{}
+}
+
+// markHardLinks заново помечает повторные ссылки, проходя дерево в текущем
+// порядке детей, и сообщает, изменилась ли хоть одна пометка. До сортировки
+// порядок не зависит от числа воркеров; после неё совпадает с выводом
+// и с потоковым режимом, где первой считается ссылка, выведенная раньше.
+func (n *Node) markHardLinks() bool {
+	links := make(linkSet)
+	changed := false
+	_ = n.Walk(func(node *Node) error {
+		dup := node.HardLinkDup
+		node.HardLinkDup = false
+		links.visit(&node.Entry)
+		changed = changed || node.HardLinkDup != dup
+		return nil
+	})
+	return changed
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package tree
+
+import "os"
+
+// linkInfo не поддерживается: жёсткие ссылки считаются отдельными файлами
+func linkInfo(os.FileInfo) (fileKey, uint64, bool) {
+	return fileKey{}, 0, false
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package tree
+
+import (
+	"os"
+	"syscall"
+)
+
+// linkInfo возвращает устройство и inode файла вместе с числом жёстких ссылок
+func linkInfo(info os.FileInfo) (fileKey, uint64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileKey{}, 0, false
+	}
+	return fileKey{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, uint64(stat.Nlink), true
+}
@@ -0,0 +1,218 @@
+package tree
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/massonsky/gotree/internal/config"
+	"github.com/massonsky/gotree/internal/types"
+)
+
+// BuildFromList строит дерево из списка путей (git ls-files, find -print0,
+// манифест сборки) вместо обхода диска. Записи разделяются переводом
+// строки или NUL, если он встречается во вводе. После пути через
+// табуляцию могут идти размер в байтах и время изменения (секунды
+// Unix, как у find -printf '%T@', или RFC 3339). Директории, которых
+// нет в списке, достраиваются по путям файлов; путь с завершающим "/"
+// считается директорией. Скрытые файлы, --ignore, --depth, --where
+// и --pattern действуют как при обходе, .gitignore не читается.
+func BuildFromList(ctx context.Context, r io.Reader, cfg *config.Config) (WalkResult, error) {
+	startTime := time.Now()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return WalkResult{}, err
+	}
+	sep := byte('\n')
+	if bytes.IndexByte(data, 0) >= 0 {
+		sep = 0
+	}
+
+	w := newWalker(ctx, cfg, nil)
+	defer w.cancel()
+
+	b := &listBuilder{w: w, nodes: make(map[string]*Node)}
+	for i, record := range bytes.Split(data, []byte{sep}) {
+		if err := ctx.Err(); err != nil {
+			return WalkResult{}, err
+		}
+		line := strings.TrimSuffix(string(record), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if err := b.add(line); err != nil {
+			return WalkResult{}, fmt.Errorf("path list, entry %d: %w", i+1, err)
+		}
+	}
+	if b.root == nil {
+		return WalkResult{}, fmt.Errorf("path list is empty")
+	}
+
+	b.root.markSelected(w, false)
+
+	// Список может идти в любом порядке, а обход отдаёт имена по алфавиту
+	b.root.sortChildren(SortOptions{Mode: SortName})
+	return w.run(b.root, nil, "path list", startTime)
+}
+
+// listBuilder собирает дерево из записей списка
+type listBuilder struct {
+	w     *walker
+	src   osFS             // где искать содержимое файлов для Node.Open
+	root  *Node            // создаётся по первой записи: "/" или "."
+	nodes map[string]*Node // по пути от корня через "/"
+}
+
+// add разбирает запись и добавляет путь вместе с недостающими предками
+func (b *listBuilder) add(line string) error {
+	fields := strings.Split(line, "\t")
+	if len(fields) > 3 {
+		return fmt.Errorf("expected at most 3 tab-separated columns, got %d", len(fields))
+	}
+
+	name := filepath.ToSlash(fields[0])
+	info := &listInfo{dir: strings.HasSuffix(name, "/")}
+	if len(fields) > 1 && fields[1] != "" {
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil || size < 0 {
+			return fmt.Errorf("invalid size %q", fields[1])
+		}
+		info.size = size
+	}
+	if len(fields) > 2 && fields[2] != "" {
+		mtime, err := parseListTime(fields[2])
+		if err != nil {
+			return fmt.Errorf("invalid mtime %q", fields[2])
+		}
+		info.mtime = mtime
+	}
+
+	if b.root == nil {
+		b.initRoot(path.IsAbs(name))
+	}
+	rel := strings.TrimLeft(path.Clean("/"+name), "/")
+	if rel == "" {
+		// Запись о самом корне, как первая строка вывода find
+		b.root.Info.(*listInfo).mtime = info.mtime
+		return nil
+	}
+
+	parts := strings.Split(rel, "/")
+	if b.filtered(parts) {
+		return nil
+	}
+	// Глубже лимита: предки остаются, сама запись — нет
+	truncated := b.w.cfg.MaxDepth > 0 && len(parts) > b.w.cfg.MaxDepth
+	if truncated {
+		parts = parts[:b.w.cfg.MaxDepth]
+	}
+
+	parent := b.root
+	for i := range parts {
+		key := strings.Join(parts[:i+1], "/")
+		node, ok := b.nodes[key]
+		if !ok {
+			node = NewNode(types.Entry{
+				Path:  filepath.FromSlash(key),
+				Info:  &listInfo{name: parts[i], dir: true},
+				Depth: i + 1,
+			}, parent)
+			node.src = location{fsys: b.src, name: key}
+			b.nodes[key] = node
+		}
+		// Запись, у которой появились дети, становится директорией
+		parent.Info.(*listInfo).dir = true
+		parent = node
+	}
+	if truncated {
+		parent.Info.(*listInfo).dir = true
+		return nil
+	}
+
+	info.name = parts[len(parts)-1]
+	info.dir = info.dir || len(parent.Children) > 0
+	parent.Info = info
+	return nil
+}
+
+// markSelected применяет к готовому поддереву отбор, как readDir при обходе
+func (n *Node) markSelected(w *walker, inherited bool) {
+	for _, child := range n.Children {
+		// Список путей может не совпадать с диском, тип берём только из имени
+		w.detectType(&child.Entry, location{})
+		show, includeAll := w.selected(child.Entry, inherited)
+		child.ancestorOnly = !show
+		child.markSelected(w, includeAll)
+	}
+}
+
+// initRoot создаёт корень: "/" для абсолютных путей, иначе "."
+func (b *listBuilder) initRoot(abs bool) {
+	name, dir := ".", "."
+	if abs {
+		name, dir = "/", "/"
+	} else if wd, err := os.Getwd(); err == nil {
+		dir = wd
+	}
+	b.src = osFS{dir: dir}
+	b.root = NewNode(types.Entry{
+		Path:  name,
+		Info:  &listInfo{name: name, dir: true},
+		Depth: 0,
+	}, nil)
+	b.root.src = location{fsys: b.src, name: "."}
+}
+
+// filtered сообщает, отброшен ли путь скрытыми файлами или --ignore.
+// Как и при обходе, отброшенная директория скрывает всё поддерево.
+func (b *listBuilder) filtered(parts []string) bool {
+	for i, part := range parts {
+		if !b.w.cfg.ShowHiddenFiles && strings.HasPrefix(part, ".") {
+			return true
+		}
+		if b.w.isIgnored(filepath.FromSlash(strings.Join(parts[:i+1], "/"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseListTime разбирает секунды Unix с дробной частью или RFC 3339
+func parseListTime(s string) (time.Time, error) {
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		whole := int64(secs)
+		return time.Unix(whole, int64((secs-float64(whole))*1e9)), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// listInfo сведения о записи из списка путей. Тип определяется
+// завершающим "/" или появлением детей, поэтому поле dir изменяемо.
+type listInfo struct {
+	name  string
+	size  int64
+	mtime time.Time
+	dir   bool
+}
+
+func (l *listInfo) Name() string       { return l.name }
+func (l *listInfo) Size() int64        { return l.size }
+func (l *listInfo) ModTime() time.Time { return l.mtime }
+func (l *listInfo) IsDir() bool        { return l.dir }
+func (l *listInfo) Sys() any           { return nil }
+
+func (l *listInfo) Mode() fs.FileMode {
+	if l.dir {
+		return fs.ModeDir | 0o755
+	}
+	return 0o644
+}
@@ -0,0 +1,95 @@
+package tree
+
+import (
+	"context"
+	"sync"
+
+	"github.com/massonsky/gotree/internal/config"
+	"github.com/massonsky/gotree/internal/loc"
+	"github.com/massonsky/gotree/internal/logger"
+)
+
+// CountLines считает строки кода, комментариев и пустые в файлах
+// известных языков (см. loc.Supported) и записывает их в Entry.Loc,
+// а директориям — сумму по поддереву. Повторные жёсткие ссылки
+// не считаются. Файл, который не удалось прочитать, получает ошибку
+// OpLoc; эти ошибки возвращаются в порядке вывода.
+func CountLines(ctx context.Context, root *Node, cfg *config.Config) ([]ScanError, error) {
+	var files []*Node
+	for _, node := range hashableFiles(root) {
+		if !node.HardLinkDup && loc.Supported(node.Lang) {
+			files = append(files, node)
+		}
+	}
+	logger.Debugf("Counting lines in %d file(s)", len(files))
+
+	// Каждый узел обрабатывает ровно одна горутина, поэтому Loc, Err
+	// и failed[i] пишутся без блокировок
+	failed := make([]*ScanError, len(files))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(hashJobs(cfg), len(files)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				node := files[i]
+				counts, err := countFile(node)
+				if err != nil {
+					logger.Warnf("Line count error: %v", err)
+					failed[i] = newScanError(node.Path, OpLoc, err)
+					node.Err = failed[i]
+					continue
+				}
+				node.Loc = counts
+			}
+		}()
+	}
+
+	var err error
+feed:
+	for i := range files {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			err = ctx.Err()
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	root.sumLines()
+	var scanErrs []ScanError
+	for _, scanErr := range failed {
+		if scanErr != nil {
+			scanErrs = append(scanErrs, *scanErr)
+		}
+	}
+	return scanErrs, nil
+}
+
+// countFile считает строки одного файла
+func countFile(node *Node) (loc.Counts, error) {
+	f, err := node.Open()
+	if err != nil {
+		return loc.Counts{}, err
+	}
+	defer f.Close()
+	return loc.Count(f, node.Lang)
+}
+
+// sumLines складывает счётчики файлов в директории
+func (n *Node) sumLines() {
+	if len(n.Children) == 0 {
+		return
+	}
+	n.Loc = loc.Counts{}
+	for _, child := range n.Children {
+		child.sumLines()
+		n.Loc.Add(child.Loc)
+	}
+}
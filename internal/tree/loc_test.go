@@ -0,0 +1,44 @@
+package tree
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/massonsky/gotree/internal/config"
+)
+
+func TestCountLinesErrors(t *testing.T) {
+	fsys := unreadableFS{
+		MapFS: fstest.MapFS{
+			"root/main.go":     {Data: []byte("package main\n\nfunc main() {}\n")},
+			"root/lib/util.go": {Data: []byte("package lib\n")},
+		},
+		denied: map[string]bool{"root/lib/util.go": true},
+	}
+
+	result, err := WalkFS(context.Background(), fsys, "root", &config.Config{Loc: true})
+	if err != nil {
+		t.Fatalf("WalkFS: %v", err)
+	}
+	want := []ScanError{{Path: "lib/util.go", Op: OpLoc, Err: fs.ErrPermission}}
+	if !slices.Equal(result.Errors, want) {
+		t.Errorf("errors = %v, want %v", result.Errors, want)
+	}
+	if result.Metrics.Errors != 1 {
+		t.Errorf("metrics errors = %d, want 1", result.Metrics.Errors)
+	}
+	// Прочитанный файл посчитан, а в сумму корня входит только он
+	if got := result.Root.Loc.Code; got != 2 {
+		t.Errorf("root code lines = %d, want 2", got)
+	}
+
+	_, err = WalkFS(context.Background(), fsys, "root", &config.Config{Loc: true, Strict: true})
+	var scanErr *ScanError
+	if !errors.As(err, &scanErr) || scanErr.Op != OpLoc {
+		t.Errorf("strict walk error = %v, want loc error", err)
+	}
+}
@@ -0,0 +1,120 @@
+package tree
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/massonsky/gotree/internal/config"
+	"github.com/massonsky/gotree/internal/filter"
+	"github.com/massonsky/gotree/internal/logger"
+	"github.com/massonsky/gotree/internal/types"
+
+	"github.com/gobwas/glob"
+)
+
+// includePattern шаблон --pattern. Шаблон со "/" сопоставляется с путём
+// от корня, остальные — с именем, как -P у GNU tree.
+type includePattern struct {
+	g      glob.Glob
+	byPath bool
+}
+
+// SplitPatterns разбирает значения --pattern: как и у GNU tree,
+// альтернативы внутри одного значения разделяются "|"
+func SplitPatterns(values []string) []string {
+	var out []string
+	for _, value := range values {
+		for _, p := range strings.Split(value, "|") {
+			if p = strings.TrimSpace(p); p != "" {
+				out = append(out, p)
+			}
+		}
+	}
+	return out
+}
+
+// initSelection компилирует --where, --pattern и --regex. Ошибки CLI
+// проверяет заранее, здесь неверные значения только пропускаются.
+func (w *walker) initSelection(cfg *config.Config) {
+	if cfg.Where != "" {
+		where, err := filter.Compile(cfg.Where, cfg.Filters)
+		if err != nil {
+			logger.Warnf("Invalid --where expression, showing all entries: %v", err)
+		} else {
+			w.where = where
+		}
+	}
+
+	for _, pattern := range SplitPatterns(cfg.IncludePatterns) {
+		g, err := glob.Compile(pattern)
+		if err != nil {
+			logger.Warnf("Invalid include pattern %q: %v", pattern, err)
+			continue
+		}
+		w.include = append(w.include, includePattern{g: g, byPath: strings.Contains(pattern, "/")})
+	}
+	for _, expr := range cfg.IncludeRegex {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			logger.Warnf("Invalid include regex %q: %v", expr, err)
+			continue
+		}
+		w.includeRe = append(w.includeRe, re)
+	}
+	w.filtering = len(w.include) > 0 || len(w.includeRe) > 0
+}
+
+// selected решает, показывать ли запись саму по себе. inherited — предок
+// совпал с шаблоном при --matchdirs, тогда поддерево включается целиком.
+// Второе значение передаётся детям директории как inherited.
+//
+// Директория, которая не показывается сама по себе, всё равно читается
+// и остаётся в дереве, если в ней нашлись совпадения (см. prune).
+func (w *walker) selected(entry types.Entry, inherited bool) (show, includeAll bool) {
+	isDir := entry.Info != nil && entry.Info.IsDir()
+
+	included := inherited || !w.filtering
+	if !included && w.matchesPattern(entry) {
+		included = !isDir || w.cfg.MatchDirs
+	}
+	show = included && (w.where == nil || w.where.Match(entry))
+	return show, included && isDir
+}
+
+// matchesPattern проверяет запись по --pattern и --regex
+func (w *walker) matchesPattern(entry types.Entry) bool {
+	name := filepath.Base(entry.Path)
+	slashPath := filepath.ToSlash(entry.Path)
+	for _, p := range w.include {
+		if p.byPath && p.g.Match(slashPath) || !p.byPath && p.g.Match(name) {
+			return true
+		}
+	}
+	for _, re := range w.includeRe {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// prune убирает узлы, которые не прошли отбор и не содержат совпадений,
+// а при emptyDirs (--prune) ещё и пустые директории
+func (n *Node) prune(emptyDirs bool) {
+	kept := n.Children[:0]
+	for _, child := range n.Children {
+		child.prune(emptyDirs)
+		if len(child.Children) == 0 {
+			if child.ancestorOnly {
+				continue
+			}
+			// Директорию с ошибкой чтения оставляем: пустой она может быть из-за ошибки
+			if emptyDirs && child.IsDir() && child.Err == nil {
+				continue
+			}
+		}
+		kept = append(kept, child)
+	}
+	n.Children = kept
+}
@@ -0,0 +1,56 @@
+package tree
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/massonsky/gotree/internal/logger"
+	"github.com/massonsky/gotree/internal/types"
+)
+
+// mountTable типы файловых систем по путям точек монтирования.
+// Таблица читается один раз, при первой найденной точке монтирования.
+type mountTable struct {
+	once   sync.Once
+	fsType map[string]string
+}
+
+func (t *mountTable) lookup(dir string) string {
+	t.once.Do(func() {
+		var err error
+		if t.fsType, err = readMountTable(); err != nil {
+			logger.Warnf("Cannot read mount table: %v", err)
+		}
+	})
+	if fsType, ok := t.fsType[dir]; ok {
+		return fsType
+	}
+	return "?"
+}
+
+// checkMount помечает директорию на другом устройстве, чем родитель,
+// как точку монтирования. Возвращает false, если в неё не нужно
+// спускаться: --one-file-system или тип из skip_fs_types.
+func (w *walker) checkMount(entry *types.Entry, parent *Node, loc location) bool {
+	if parent.Info == nil {
+		return true
+	}
+	parentDev, ok := deviceID(parent.Info)
+	if !ok {
+		return true
+	}
+	dev, ok := deviceID(entry.Info)
+	if !ok || dev == parentDev {
+		return true
+	}
+
+	entry.Mount = "?"
+	if diskPath, ok := loc.diskPath(); ok {
+		entry.Mount = w.mounts.lookup(diskPath)
+	}
+	if w.cfg.OneFileSystem || slices.Contains(w.cfg.SkipFSTypes, entry.Mount) {
+		entry.MountSkipped = true
+		return false
+	}
+	return true
+}
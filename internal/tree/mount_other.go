@@ -0,0 +1,10 @@
+//go:build !unix
+
+package tree
+
+import "os"
+
+// deviceID не поддерживается: точки монтирования не определяются
+func deviceID(os.FileInfo) (uint64, bool) {
+	return 0, false
+}
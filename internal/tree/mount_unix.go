@@ -0,0 +1,17 @@
+//go:build unix
+
+package tree
+
+import (
+	"os"
+	"syscall"
+)
+
+// deviceID возвращает номер устройства, на котором лежит запись
+func deviceID(info os.FileInfo) (uint64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Dev), true
+}
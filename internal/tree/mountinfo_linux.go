@@ -0,0 +1,56 @@
+package tree
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// readMountTable читает /proc/self/mountinfo: пятое поле — точка
+// монтирования, первое поле после "-" — тип файловой системы
+func readMountTable() (map[string]string, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	table := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		sep := -1
+		for i, field := range fields {
+			if field == "-" {
+				sep = i
+				break
+			}
+		}
+		if len(fields) < 5 || sep < 0 || sep+1 >= len(fields) {
+			continue
+		}
+		table[unescapeMountPath(fields[4])] = fields[sep+1]
+	}
+	return table, scanner.Err()
+}
+
+// unescapeMountPath раскрывает восьмеричные escape-последовательности
+// вроде "\040" (пробел), которыми ядро заменяет пробельные символы
+func unescapeMountPath(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
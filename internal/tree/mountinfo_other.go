@@ -0,0 +1,9 @@
+//go:build !linux
+
+package tree
+
+// readMountTable не поддерживается: типы файловых систем неизвестны,
+// точки монтирования помечаются как "?"
+func readMountTable() (map[string]string, error) {
+	return nil, nil
+}
@@ -0,0 +1,385 @@
+package tree
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/massonsky/gotree/internal/metrics"
+	"github.com/massonsky/gotree/internal/types"
+)
+
+// Node — узел иерархического дерева, которое строит обходчик.
+// Дети хранятся в порядке вывода, агрегаты считаются по всему поддереву.
+type Node struct {
+	types.Entry
+	Parent   *Node
+	Children []*Node
+
+	// Агрегированные значения поддерева (для файла — его собственные).
+	// Size считается в режиме, переданном в Aggregate.
+	Size      int64
+	FileCount int
+	DirCount  int
+
+	src location // откуда читать содержимое, см. Open
+
+	// Узел не прошёл --where или --pattern и остаётся в дереве, только если
+	// в его поддереве нашлись совпадения (см. prune)
+	ancestorOnly bool
+}
+
+// PrefixStyle набор символов для рисования соединителей дерева
+type PrefixStyle struct {
+	Vertical string // продолжение ветки предка: "│   "
+	Blank    string // предок был последним: "    "
+	Branch   string // элемент не последний: "├── "
+	Corner   string // последний элемент: "└── "
+}
+
+// BoxPrefixStyle — псевдографика, как у GNU tree
+var BoxPrefixStyle = PrefixStyle{
+	Vertical: "│   ",
+	Blank:    "    ",
+	Branch:   "├── ",
+	Corner:   "└── ",
+}
+
+// ASCIIPrefixStyle — для шрифтов без символов псевдографики
+var ASCIIPrefixStyle = PrefixStyle{
+	Vertical: "|   ",
+	Blank:    "    ",
+	Branch:   "+-- ",
+	Corner:   "`-- ",
+}
+
+// NewNode создаёт узел для записи и привязывает его к родителю
+func NewNode(entry types.Entry, parent *Node) *Node {
+	n := &Node{Entry: entry}
+	if parent != nil {
+		parent.AddChild(n)
+	}
+	return n
+}
+
+// AddChild добавляет дочерний узел в конец списка детей
+func (n *Node) AddChild(child *Node) {
+	child.Parent = n
+	n.Children = append(n.Children, child)
+}
+
+// Open открывает содержимое узла: файл на диске или внутри архива.
+// Записи архивов доступны, пока не закрыт WalkResult.
+func (n *Node) Open() (fs.File, error) {
+	if n.src.fsys == nil {
+		return nil, &fs.PathError{Op: "open", Path: n.Path, Err: fs.ErrInvalid}
+	}
+	return n.src.fsys.Open(n.src.name)
+}
+
+// OnDisk сообщает, лежит ли запись на диске, а не внутри архива
+func (n *Node) OnDisk() bool {
+	_, ok := n.src.diskPath()
+	return ok
+}
+
+// IsRoot сообщает, является ли узел корнем дерева
+func (n *Node) IsRoot() bool {
+	return n.Parent == nil
+}
+
+// Root возвращает корень дерева, в которое входит узел
+func (n *Node) Root() *Node {
+	for n.Parent != nil {
+		n = n.Parent
+	}
+	return n
+}
+
+// IsDir сообщает, является ли узел директорией
+func (n *Node) IsDir() bool {
+	return n.Info != nil && n.Info.IsDir()
+}
+
+// Name возвращает отображаемое имя узла (для корня — путь целиком,
+// для сводки --max-children — её текст)
+func (n *Node) Name() string {
+	if n.Omitted > 0 {
+		return n.omittedLabel()
+	}
+	if n.IsRoot() {
+		return n.Path
+	}
+	return filepath.Base(n.Path)
+}
+
+// LinkLabel возвращает подпись вида " -> target" для символических ссылок
+// с пометкой для битых и рекурсивных ссылок, для прочих узлов — ""
+func (n *Node) LinkLabel() string {
+	if !n.IsSymlink() {
+		return ""
+	}
+	label := " -> " + n.LinkTarget
+	switch {
+	case n.Broken:
+		label += "  [broken]"
+	case n.Recursive:
+		label += "  [recursive, not followed]"
+	}
+	return label
+}
+
+// LinksLabel возвращает число жёстких ссылок: "1 link" или "N links"
+// с пометкой повторной ссылки
+func (n *Node) LinksLabel() string {
+	label := fmt.Sprintf("%d links", n.Links)
+	if n.Links == 1 {
+		label = "1 link"
+	}
+	if n.HardLinkDup {
+		label += ", counted once"
+	}
+	return label
+}
+
+// HistoryLabel возвращает подпись истории git: дату последнего коммита,
+// автора и число коммитов; "" — записи нет в истории
+func (n *Node) HistoryLabel() string {
+	if n.Commits == 0 {
+		return ""
+	}
+	commits := fmt.Sprintf("%d commits", n.Commits)
+	if n.Commits == 1 {
+		commits = "1 commit"
+	}
+	return fmt.Sprintf(" [%s, %s, %s]", n.LastCommit.Format("2006-01-02"), n.LastAuthor, commits)
+}
+
+// LocLabel возвращает подпись подсчёта строк при --loc, для директорий —
+// по всему поддереву
+func (n *Node) LocLabel() string {
+	if n.Loc.Files == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" [%d code, %d comment, %d blank]", n.Loc.Code, n.Loc.Comment, n.Loc.Blank)
+}
+
+// ErrorLabel возвращает пометку об ошибке чтения узла или ""
+func (n *Node) ErrorLabel() string {
+	if n.Err == nil {
+		return ""
+	}
+	var scanErr *ScanError
+	if errors.As(n.Err, &scanErr) {
+		return "  " + scanErr.Label()
+	}
+	return "  [error]"
+}
+
+// MountLabel возвращает пометку точки монтирования вида "  [mount: nfs]" или ""
+func (n *Node) MountLabel() string {
+	if n.Mount == "" {
+		return ""
+	}
+	if n.MountSkipped {
+		return "  [mount: " + n.Mount + ", not crossed]"
+	}
+	return "  [mount: " + n.Mount + "]"
+}
+
+// LimitLabel возвращает пометку директории, пропущенной из-за --filelimit,
+// как у GNU tree, или ""
+func (n *Node) LimitLabel() string {
+	if n.OverLimit == 0 {
+		return ""
+	}
+	return fmt.Sprintf("  [%d entries exceeds filelimit, not opening dir]", n.OverLimit)
+}
+
+// FilesLabel возвращает число файлов поддерева: "1 file" или "N files"
+func (n *Node) FilesLabel() string {
+	if n.FileCount == 1 {
+		return "1 file"
+	}
+	return fmt.Sprintf("%d files", n.FileCount)
+}
+
+// Suffix возвращает все пометки, которые выводятся после имени узла
+func (n *Node) Suffix() string {
+	return n.LinkLabel() + n.MountLabel() + n.LimitLabel() + n.ErrorLabel()
+}
+
+// IsLast сообщает, является ли узел последним среди детей родителя
+func (n *Node) IsLast() bool {
+	if n.Parent == nil {
+		return true
+	}
+	siblings := n.Parent.Children
+	return len(siblings) > 0 && siblings[len(siblings)-1] == n
+}
+
+// Prefix строит соединители слева от имени узла
+func (n *Node) Prefix(style PrefixStyle) string {
+	return n.PrefixUnder(nil, style)
+}
+
+// PrefixUnder рисует соединители так, будто дерево начинается с предка
+// top, — для показа отдельного поддерева; nil означает корень дерева
+func (n *Node) PrefixUnder(top *Node, style PrefixStyle) string {
+	if n == top || n.IsRoot() {
+		return ""
+	}
+
+	// Собираем части от узла к корню, затем переворачиваем
+	parts := []string{style.Branch}
+	if n.IsLast() {
+		parts[0] = style.Corner
+	}
+	for a := n.Parent; a != nil && a != top && !a.IsRoot(); a = a.Parent {
+		if a.IsLast() {
+			parts = append(parts, style.Blank)
+		} else {
+			parts = append(parts, style.Vertical)
+		}
+	}
+
+	var b strings.Builder
+	for i := len(parts) - 1; i >= 0; i-- {
+		b.WriteString(parts[i])
+	}
+	return b.String()
+}
+
+// Walk обходит поддерево в прямом порядке (узел, затем дети).
+// Возврат ошибки из fn прерывает обход.
+func (n *Node) Walk(fn func(*Node) error) error {
+	if err := fn(n); err != nil {
+		return err
+	}
+	for _, child := range n.Children {
+		if err := child.Walk(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flatten разворачивает поддерево в плоский список записей в порядке вывода
+func (n *Node) Flatten() []types.Entry {
+	var entries []types.Entry
+	_ = n.Walk(func(node *Node) error {
+		entries = append(entries, node.Entry)
+		return nil
+	})
+	return entries
+}
+
+// limitChildren оставляет у каждой директории не больше limit детей,
+// а остальных заменяет одним узлом-сводкой (Entry.Omitted) с их суммарным
+// размером и счётчиками. Вызывается после Aggregate и Sort.
+func (n *Node) limitChildren(limit int) {
+	n.hideChildren(limit)
+	for _, child := range n.Children {
+		child.limitChildren(limit)
+	}
+}
+
+// hideChildren заменяет детей после первых limit сводкой и возвращает
+// скрытых; nil — детей не больше limit
+func (n *Node) hideChildren(limit int) []*Node {
+	if len(n.Children) <= limit {
+		return nil
+	}
+	hidden := n.Children[limit:]
+	n.Children = n.Children[:limit:limit]
+	n.AddChild(newOmittedNode(n, hidden))
+	return hidden
+}
+
+// newOmittedNode создаёт сводку по скрытым детям директории dir
+func newOmittedNode(dir *Node, hidden []*Node) *Node {
+	sum := &Node{Entry: types.Entry{Path: dir.Path, Depth: dir.Depth + 1, Omitted: len(hidden)}}
+	for _, child := range hidden {
+		if !child.HardLinkDup {
+			sum.Size += child.Size
+		}
+		sum.FileCount += child.FileCount
+		sum.DirCount += child.DirCount
+		if child.IsDir() {
+			sum.DirCount++
+		} else {
+			sum.FileCount++
+		}
+	}
+	sum.Info = omittedInfo{size: sum.Size}
+	return sum
+}
+
+// omittedLabel текст сводки: "… 2 341 more files (120.0 MB)"
+func (n *Node) omittedLabel() string {
+	what := "files"
+	switch {
+	case n.Omitted == 1 && n.DirCount == 0:
+		what = "file"
+	case n.Omitted == 1:
+		what = "entry"
+	case n.DirCount > 0:
+		what = "entries"
+	}
+	return fmt.Sprintf("… %s more %s (%s)", groupDigits(n.Omitted), what, metrics.FormatSize(n.Size))
+}
+
+// groupDigits разбивает число на разряды пробелами: 2341 → "2 341"
+func groupDigits(v int) string {
+	s := strconv.Itoa(v)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + " " + s[i:]
+	}
+	return s
+}
+
+// omittedInfo os.FileInfo узла-сводки: обычный файл с суммарным размером
+type omittedInfo struct {
+	size int64
+}
+
+func (o omittedInfo) Name() string       { return "…" }
+func (o omittedInfo) Size() int64        { return o.size }
+func (o omittedInfo) Mode() fs.FileMode  { return 0 }
+func (o omittedInfo) ModTime() time.Time { return time.Time{} }
+func (o omittedInfo) IsDir() bool        { return false }
+func (o omittedInfo) Sys() any           { return nil }
+
+// Aggregate пересчитывает размеры и счётчики для всего поддерева.
+// В режиме SizeBlocks к размеру директории добавляются и её собственные
+// блоки, как это делает du. Раскрытый архив сохраняет свой размер
+// на диске, а содержимое добавляет только к счётчикам. Повторные
+// жёсткие ссылки (HardLinkDup) показывают свой размер, но в размер
+// директории не входят.
+func (n *Node) Aggregate(mode SizeMode) {
+	if n.Omitted > 0 {
+		return // сводка хранит итоги скрытых узлов, пересчитать их не из чего
+	}
+	n.Size, n.FileCount, n.DirCount = 0, 0, 0
+	if n.Info != nil && (!n.IsDir() || mode == SizeBlocks) {
+		n.Size = entrySize(n.Info, mode)
+	}
+
+	for _, child := range n.Children {
+		child.Aggregate(mode)
+		if n.IsDir() && !child.HardLinkDup {
+			n.Size += child.Size
+		}
+		n.FileCount += child.FileCount
+		n.DirCount += child.DirCount
+		if child.IsDir() {
+			n.DirCount++
+		} else {
+			n.FileCount++
+		}
+	}
+}
@@ -1,12 +1,24 @@
 package tree
 
 import (
+	"io"
+
 	"github.com/massonsky/gotree/internal/metrics"
 	_type "github.com/massonsky/gotree/internal/types"
 )
 
 // WalkResult содержит результат обхода директории
 type WalkResult struct {
-	Entries []_type.Entry
+	Entries []_type.Entry // плоский список в порядке вывода
+	Root    *Node         // то же дерево в иерархическом виде
+	Errors  []ScanError   // пути, которые не удалось прочитать, по алфавиту
 	Metrics metrics.Metrics
+
+	closers []io.Closer
+}
+
+// Close закрывает архивы, раскрытые при обходе. После Close содержимое
+// их записей нельзя прочитать через Node.Open.
+func (r WalkResult) Close() error {
+	return closeAll(r.closers)
 }
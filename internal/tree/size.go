@@ -0,0 +1,38 @@
+package tree
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SizeMode способ подсчёта размера файлов и поддеревьев
+type SizeMode string
+
+const (
+	SizeApparent SizeMode = "apparent" // длина файла в байтах, как в ls -l
+	SizeBlocks   SizeMode = "blocks"   // занятые на диске блоки, как в du
+)
+
+// ParseSizeMode проверяет имя режима. Пустая строка означает SizeApparent.
+func ParseSizeMode(s string) (SizeMode, error) {
+	switch strings.ToLower(s) {
+	case "", string(SizeApparent):
+		return SizeApparent, nil
+	case string(SizeBlocks):
+		return SizeBlocks, nil
+	default:
+		return "", fmt.Errorf("unknown size mode %q (supported: %s, %s)", s, SizeApparent, SizeBlocks)
+	}
+}
+
+// entrySize возвращает размер записи в выбранном режиме. Если система
+// не сообщает число блоков, используется видимый размер.
+func entrySize(info os.FileInfo, mode SizeMode) int64 {
+	if mode == SizeBlocks {
+		if size, ok := allocatedSize(info); ok {
+			return size
+		}
+	}
+	return info.Size()
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package tree
+
+import "os"
+
+// allocatedSize недоступен без st_blocks
+func allocatedSize(info os.FileInfo) (int64, bool) {
+	return 0, false
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package tree
+
+import (
+	"os"
+	"syscall"
+)
+
+// allocatedSize возвращает место на диске: st_blocks всегда в единицах по 512 байт
+func allocatedSize(info os.FileInfo) (int64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int64(stat.Blocks) * 512, true
+}
@@ -0,0 +1,216 @@
+package tree
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SortMode порядок детей внутри директории
+type SortMode string
+
+const (
+	SortName      SortMode = "name"      // побайтово, как отдаёт файловая система
+	SortNatural   SortMode = "natural"   // с учётом чисел: file2 < file10
+	SortSize      SortMode = "size"      // сначала большие, директории — по размеру поддерева
+	SortMtime     SortMode = "mtime"     // сначала новые
+	SortExtension SortMode = "extension" // по расширению, затем по имени
+
+	// По истории git, см. --git-history
+	SortCommitted SortMode = "committed" // сначала недавно изменённые в git
+	SortCommits   SortMode = "commits"   // сначала с наибольшим числом коммитов
+	SortAuthor    SortMode = "author"    // по автору последнего коммита
+)
+
+// SortModes все поддерживаемые режимы в порядке для справки
+var SortModes = []SortMode{SortName, SortNatural, SortSize, SortMtime, SortExtension, SortCommitted, SortCommits, SortAuthor}
+
+// SortOptions параметры сортировки дерева
+type SortOptions struct {
+	Mode      SortMode
+	DirsFirst bool
+	Reverse   bool
+}
+
+// ParseSortMode проверяет имя режима. Пустая строка означает SortName.
+func ParseSortMode(s string) (SortMode, error) {
+	if s == "" {
+		return SortName, nil
+	}
+	for _, mode := range SortModes {
+		if strings.EqualFold(s, string(mode)) {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("unknown sort mode %q (supported: %s)", s, joinSortModes())
+}
+
+// usesGitHistory сообщает, что для сортировки нужна история git
+func (m SortMode) usesGitHistory() bool {
+	return m == SortCommitted || m == SortCommits || m == SortAuthor
+}
+
+func joinSortModes() string {
+	names := make([]string, len(SortModes))
+	for i, mode := range SortModes {
+		names[i] = string(mode)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Sort упорядочивает детей каждого узла поддерева. Для режима size
+// агрегаты должны быть уже посчитаны (см. Aggregate).
+func (n *Node) Sort(opts SortOptions) {
+	if opts.Mode == "" {
+		opts.Mode = SortName
+	}
+	// os.ReadDir уже отдаёт имена по порядку
+	if opts.Mode == SortName && !opts.DirsFirst && !opts.Reverse {
+		return
+	}
+	n.sortChildren(opts)
+}
+
+func (n *Node) sortChildren(opts SortOptions) {
+	n.sortSiblings(opts)
+	for _, child := range n.Children {
+		child.sortChildren(opts)
+	}
+}
+
+// sortSiblings упорядочивает только непосредственных детей узла
+func (n *Node) sortSiblings(opts SortOptions) {
+	less := lessFunc(opts.Mode)
+	sort.SliceStable(n.Children, func(i, j int) bool {
+		a, b := n.Children[i], n.Children[j]
+		// Директории наверху не зависят от --reverse
+		if opts.DirsFirst && a.IsDir() != b.IsDir() {
+			return a.IsDir()
+		}
+		if opts.Reverse {
+			return less(b, a)
+		}
+		return less(a, b)
+	})
+}
+
+// lessFunc возвращает сравнение для режима; при равенстве ключей
+// порядок определяет имя, чтобы вывод был детерминированным
+func lessFunc(mode SortMode) func(a, b *Node) bool {
+	byName := func(a, b *Node) bool { return a.Name() < b.Name() }
+
+	switch mode {
+	case SortNatural:
+		return func(a, b *Node) bool {
+			if c := NaturalCompare(a.Name(), b.Name()); c != 0 {
+				return c < 0
+			}
+			return byName(a, b)
+		}
+	case SortSize:
+		return func(a, b *Node) bool {
+			if a.Size != b.Size {
+				return a.Size > b.Size
+			}
+			return byName(a, b)
+		}
+	case SortMtime:
+		return func(a, b *Node) bool {
+			ta, tb := a.Info.ModTime(), b.Info.ModTime()
+			if !ta.Equal(tb) {
+				return ta.After(tb)
+			}
+			return byName(a, b)
+		}
+	case SortExtension:
+		return func(a, b *Node) bool {
+			ea := strings.ToLower(filepath.Ext(a.Name()))
+			eb := strings.ToLower(filepath.Ext(b.Name()))
+			if ea != eb {
+				return ea < eb
+			}
+			return byName(a, b)
+		}
+	case SortCommitted:
+		return func(a, b *Node) bool {
+			if !a.LastCommit.Equal(b.LastCommit) {
+				return a.LastCommit.After(b.LastCommit)
+			}
+			return byName(a, b)
+		}
+	case SortCommits:
+		return func(a, b *Node) bool {
+			if a.Commits != b.Commits {
+				return a.Commits > b.Commits
+			}
+			return byName(a, b)
+		}
+	case SortAuthor:
+		return func(a, b *Node) bool {
+			if a.LastAuthor != b.LastAuthor {
+				return a.LastAuthor < b.LastAuthor
+			}
+			return byName(a, b)
+		}
+	default:
+		return byName
+	}
+}
+
+// NaturalCompare сравнивает строки, считая последовательности цифр
+// числами: "file2" < "file10", "v1.9" < "v1.10". Возвращает -1, 0 или 1.
+func NaturalCompare(a, b string) int {
+	for a != "" && b != "" {
+		ca, restA := nextChunk(a)
+		cb, restB := nextChunk(b)
+
+		if isDigit(ca[0]) && isDigit(cb[0]) {
+			if c := compareNumbers(ca, cb); c != 0 {
+				return c
+			}
+		} else if ca != cb {
+			// Сравниваем остаток строк, а не группы: если одна группа —
+			// начало другой, решает следующий байт ("img.png" < "img1.png")
+			return strings.Compare(a, b)
+		}
+		a, b = restA, restB
+	}
+	return strings.Compare(a, b)
+}
+
+// nextChunk отрезает от строки непрерывную группу цифр или не-цифр
+func nextChunk(s string) (string, string) {
+	digits := isDigit(s[0])
+	i := 1
+	for i < len(s) && isDigit(s[i]) == digits {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// compareNumbers сравнивает записи чисел произвольной длины без переполнения;
+// при равных значениях меньше та запись, у которой меньше ведущих нулей
+func compareNumbers(a, b string) int {
+	ta, tb := strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(ta) != len(tb) {
+		if len(ta) < len(tb) {
+			return -1
+		}
+		return 1
+	}
+	if c := strings.Compare(ta, tb); c != 0 {
+		return c
+	}
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
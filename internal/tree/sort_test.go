@@ -0,0 +1,74 @@
+package tree
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNaturalCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		// Группы цифр сравниваются как числа
+		{"file2", "file10", -1},
+		{"file10", "file2", 1},
+		{"file10", "file10", 0},
+		{"v1.9", "v1.10", -1},
+		{"v1.10.2", "v1.10.10", -1},
+		{"2", "10", -1},
+		{"a1b2", "a1b10", -1},
+		{"a2b1", "a10b1", -1},
+		{"99999999999999999999", "100000000000000000000", -1}, // длиннее int64
+
+		// Ведущие нули: значение важнее, при равных значениях меньше нулей — раньше
+		{"file007", "file10", -1},
+		{"file010", "file9", 1},
+		{"file1", "file01", -1},
+		{"file01", "file001", -1},
+		{"0", "00", -1},
+		{"file00", "file0", 1},
+
+		// Регистр учитывается, как при сортировке по имени: заглавные раньше
+		{"B", "a", -1},
+		{"File2", "file10", -1},
+		{"file2", "File10", 1},
+		{"IMG10", "IMG9", 1},
+		{"img9", "IMG10", 1},
+
+		// Общий префикс: короткая строка раньше
+		{"", "", 0},
+		{"", "a", -1},
+		{"file", "file1", -1},
+		{"file1", "file", 1},
+		{"file1", "file1a", -1},
+		{"file", "file.txt", -1},
+		{"abc", "abd", -1},
+
+		// Цифры и не-цифры в одной позиции — побайтово
+		{"1a", "a1", -1},
+		{"a", "1", 1},
+		{"file-2", "file2", -1},
+		{"x.10", "x10", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"|"+tt.b, func(t *testing.T) {
+			if got := NaturalCompare(tt.a, tt.b); got != tt.want {
+				t.Errorf("NaturalCompare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+			if got := NaturalCompare(tt.b, tt.a); got != -tt.want {
+				t.Errorf("NaturalCompare(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+			}
+		})
+	}
+}
+
+func TestNaturalCompareSort(t *testing.T) {
+	names := []string{"img12.png", "img10.png", "IMG3.png", "img2.png", "img1.png", "img01.png", "img.png"}
+	slices.SortFunc(names, NaturalCompare)
+	want := []string{"IMG3.png", "img.png", "img1.png", "img01.png", "img2.png", "img10.png", "img12.png"}
+	if !slices.Equal(names, want) {
+		t.Errorf("sorted = %q, want %q", names, want)
+	}
+}
@@ -0,0 +1,217 @@
+package tree
+
+import (
+	"context"
+	"io/fs"
+	"iter"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/massonsky/gotree/internal/config"
+	"github.com/massonsky/gotree/internal/logger"
+	"github.com/massonsky/gotree/internal/metrics"
+)
+
+// Stream потоковый обход директории: узлы отдаются по мере чтения,
+// а в памяти держится только путь от корня до текущей директории
+// вместе с соседями узлов на этом пути (они нужны для соединителей).
+//
+// Ограничения потока: агрегаты директорий (Size, FileCount) на момент
+// вывода неизвестны и равны нулю, а сортировка применяется только
+// внутри одной директории. Обход всегда последовательный. Директории,
+// не прошедшие --where или --pattern, выводятся всегда: на момент
+// вывода неизвестно, найдутся ли в них совпадения; --prune не действует.
+// Директории, скрытые --max-children, не читаются: в сводке и метриках
+// учтены только они сами, без содержимого.
+type Stream struct {
+	ctx  context.Context
+	fsys fs.FS // nil — диск
+	root string
+	cfg  *config.Config
+
+	metrics metrics.Metrics
+	errors  []ScanError
+}
+
+// NewStream готовит потоковый обход; чтение начинается в Nodes
+func NewStream(ctx context.Context, root string, cfg *config.Config) *Stream {
+	return &Stream{ctx: ctx, root: root, cfg: cfg}
+}
+
+// NewStreamFS готовит потоковый обход директории root внутри произвольной
+// файловой системы, как WalkFS
+func NewStreamFS(ctx context.Context, fsys fs.FS, root string, cfg *config.Config) *Stream {
+	return &Stream{ctx: ctx, fsys: fsys, root: root, cfg: cfg}
+}
+
+// Nodes возвращает итератор по узлам в порядке вывода (прямой обход).
+// Ошибки отдельных путей прикрепляются к узлам, как в WalkDirWithContext.
+// Фатальная ошибка (отмена, --strict, недоступный корень) отдаётся
+// последней парой с nil-узлом. Дети узла освобождаются, как только
+// директория выведена целиком, поэтому узлы не стоит хранить.
+func (s *Stream) Nodes() iter.Seq2[*Node, error] {
+	return func(yield func(*Node, error) bool) {
+		startTime := time.Now()
+		s.metrics, s.errors = metrics.Metrics{}, nil
+
+		w := newWalker(s.ctx, s.cfg, nil)
+		defer w.cancel()
+		w.sem = nil // поток всегда последовательный
+
+		root := s.root
+		var rootNode *Node
+		var task *dirTask
+		var err error
+		if s.fsys != nil {
+			rootNode, task, err = w.openRootFS(s.fsys, root)
+		} else if root, err = filepath.Abs(root); err == nil {
+			rootNode, task, err = w.openRoot(root)
+		}
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		// Архивы закрываются после последнего узла
+		defer func() { _ = closeAll(w.closers) }()
+
+		sv := &streamVisitor{
+			w:      w,
+			s:      s,
+			yield:  yield,
+			sort:   sortOptions(s.cfg),
+			size:   sizeMode(s.cfg),
+			links:  make(linkSet),
+			hidden: make(map[*Node][]*Node),
+		}
+		logger.Debugf("Streaming walk of %s", root)
+
+		rootNode.Aggregate(sv.size)
+		// Директория читается до вывода, чтобы ошибка чтения попала в её строку
+		var descend map[*Node]dirTask
+		ok := true
+		if task != nil {
+			descend, ok = sv.read(*task)
+		}
+		if ok && sv.emit(rootNode) && task != nil {
+			sv.walk(rootNode, descend)
+		}
+
+		sort.Slice(w.errors, func(i, j int) bool {
+			return w.errors[i].Path < w.errors[j].Path
+		})
+		s.errors = w.errors
+		s.metrics.Errors = len(w.errors)
+		s.metrics.Workers = 1
+		s.metrics.Finish(startTime)
+		logger.Infof("Streamed %d entries from %s", s.metrics.TotalFiles+s.metrics.TotalDirs-1, root)
+
+		if w.err != nil && !sv.stopped {
+			if w.err == context.Canceled {
+				logger.Warn("Directory walk cancelled by user")
+			}
+			yield(nil, w.err)
+		}
+	}
+}
+
+// Metrics возвращает метрики, накопленные последним проходом Nodes
+func (s *Stream) Metrics() metrics.Metrics {
+	return s.metrics
+}
+
+// Errors возвращает ошибки путей последнего прохода Nodes, по алфавиту
+func (s *Stream) Errors() []ScanError {
+	return s.errors
+}
+
+// streamVisitor состояние одного прохода потока
+type streamVisitor struct {
+	w       *walker
+	s       *Stream
+	yield   func(*Node, error) bool
+	sort    SortOptions
+	size    SizeMode
+	links   linkSet
+	hidden  map[*Node][]*Node // сводка --max-children → скрытые ею дети
+	stopped bool              // потребитель прекратил итерацию
+}
+
+// emit отдаёт узел потребителю и учитывает его в метриках. Скрытые
+// сводкой дети учитываются на её месте, в том же порядке, что и в
+// обычном обходе, и только потом считается её размер.
+func (sv *streamVisitor) emit(node *Node) bool {
+	if node.Omitted == 0 {
+		sv.links.visit(&node.Entry)
+		sv.s.metrics.Add(node.Entry, node.Size)
+	} else if hidden, ok := sv.hidden[node]; ok {
+		delete(sv.hidden, node)
+		for _, child := range hidden {
+			sv.links.visit(&child.Entry)
+			sv.s.metrics.Add(child.Entry, child.Size)
+		}
+		sum := newOmittedNode(node.Parent, hidden)
+		node.Size, node.Info = sum.Size, sum.Info
+	}
+	if !sv.yield(node, nil) {
+		sv.stopped = true
+		return false
+	}
+	return true
+}
+
+// read читает директорию и готовит её детей к выводу: размеры, порядок,
+// сводку --max-children. Возвращает поддиректории, в которые нужно
+// спуститься, и false, если обход нужно прекратить.
+func (sv *streamVisitor) read(task dirTask) (map[*Node]dirTask, bool) {
+	dir := task.node
+	subdirs, err := sv.w.readDir(task)
+	if err != nil {
+		sv.w.fail(err)
+	}
+	if sv.w.err != nil {
+		return nil, false
+	}
+	// Без агрегатов поддерева директории получают только свой размер
+	for _, child := range dir.Children {
+		child.Aggregate(sv.size)
+	}
+	dir.sortSiblings(sv.sort)
+	if limit := sv.w.cfg.MaxChildren; limit > 0 {
+		if hidden := dir.hideChildren(limit); hidden != nil {
+			sv.hidden[dir.Children[len(dir.Children)-1]] = hidden
+		}
+	}
+
+	descend := make(map[*Node]dirTask, len(subdirs))
+	for _, sub := range subdirs {
+		descend[sub.node] = sub
+	}
+	return descend, true
+}
+
+// walk выводит детей прочитанной директории и спускается в них.
+// Поддиректория читается до вывода своей строки, чтобы пометки об ошибке
+// и --filelimit были известны. Возвращает false, если обход нужно прекратить.
+func (sv *streamVisitor) walk(dir *Node, descend map[*Node]dirTask) bool {
+	for _, child := range dir.Children {
+		sub, isDir := descend[child]
+		var subDescend map[*Node]dirTask
+		if isDir {
+			var ok bool
+			if subDescend, ok = sv.read(sub); !ok {
+				return false
+			}
+		}
+		if !sv.emit(child) {
+			return false
+		}
+		if isDir && !sv.walk(child, subDescend) {
+			return false
+		}
+	}
+
+	// Директория выведена целиком — соседи её детей больше не нужны
+	dir.Children = nil
+	return true
+}
@@ -0,0 +1,229 @@
+package tree
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/massonsky/gotree/internal/config"
+)
+
+// errFS файловая система, в которой чтение перечисленных директорий
+// завершается ошибкой доступа, как у директории с правами 000
+type errFS struct {
+	fstest.MapFS
+	denied map[string]bool
+}
+
+func (f errFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if f.denied[name] {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return f.MapFS.ReadDir(name)
+}
+
+// treeLines строки дерева без иконок и размеров: соединители, имя и пометки
+func treeLines(root *Node) []string {
+	var lines []string
+	_ = root.Walk(func(node *Node) error {
+		lines = append(lines, node.Prefix(BoxPrefixStyle)+node.Name()+node.Suffix())
+		return nil
+	})
+	return lines
+}
+
+// streamLines те же строки, собранные в момент вывода узлов потоком
+func streamLines(t *testing.T, stream *Stream) []string {
+	t.Helper()
+	var lines []string
+	for node, err := range stream.Nodes() {
+		if err != nil {
+			t.Fatalf("stream: %v", err)
+		}
+		lines = append(lines, node.Prefix(BoxPrefixStyle)+node.Name()+node.Suffix())
+	}
+	return lines
+}
+
+func TestStreamMatchesWalk(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fs.FS
+		cfg  config.Config
+		want []string
+	}{
+		{
+			name: "unreadable directory",
+			fsys: errFS{
+				MapFS: fstest.MapFS{
+					"root/a.txt":          {Data: []byte("a")},
+					"root/locked/secret":  {Data: []byte("s")},
+					"root/open/b.txt":     {Data: []byte("b")},
+					"root/open/deep/c.go": {Data: []byte("c")},
+				},
+				denied: map[string]bool{"root/locked": true},
+			},
+			want: []string{
+				"root",
+				"├── a.txt",
+				"├── locked  [error opening dir]",
+				"└── open",
+				"    ├── b.txt",
+				"    └── deep",
+				"        └── c.go",
+			},
+		},
+		{
+			name: "unreadable root",
+			fsys: errFS{
+				MapFS:  fstest.MapFS{"root/a.txt": {Data: []byte("a")}},
+				denied: map[string]bool{"root": true},
+			},
+			want: []string{"root  [error opening dir]"},
+		},
+		{
+			name: "directory over filelimit",
+			fsys: fstest.MapFS{
+				"root/big/1":     {Data: []byte("1")},
+				"root/big/2":     {Data: []byte("2")},
+				"root/big/3":     {Data: []byte("3")},
+				"root/small/1":   {Data: []byte("1")},
+				"root/small/2/x": {Data: []byte("x")},
+			},
+			cfg: config.Config{FileLimit: 2},
+			want: []string{
+				"root",
+				"├── big  [3 entries exceeds filelimit, not opening dir]",
+				"└── small",
+				"    ├── 1",
+				"    └── 2",
+				"        └── x",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := WalkFS(context.Background(), tt.fsys, "root", &tt.cfg)
+			if err != nil {
+				t.Fatalf("WalkFS: %v", err)
+			}
+			walked := treeLines(result.Root)
+			if !slices.Equal(walked, tt.want) {
+				t.Errorf("WalkFS lines:\n%q\nwant:\n%q", walked, tt.want)
+			}
+
+			streamed := streamLines(t, NewStreamFS(context.Background(), tt.fsys, "root", &tt.cfg))
+			if !slices.Equal(streamed, walked) {
+				t.Errorf("stream lines:\n%q\nwalk lines:\n%q", streamed, walked)
+			}
+		})
+	}
+}
+
+// linkLines строки дерева с числом жёстких ссылок и пометкой повторной ссылки
+func linkLines(nodes []*Node) []string {
+	var lines []string
+	for _, node := range nodes {
+		line := node.Prefix(BoxPrefixStyle) + node.Name()
+		if node.Links > 1 {
+			line += "  [" + node.LinksLabel() + "]"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestStreamHardLinks(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "root")
+	if err := os.MkdirAll(filepath.Join(root, "a"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string]string{"a/x": "12345", "d": "d"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"b", "c"} {
+		if err := os.Link(filepath.Join(root, "a", "x"), filepath.Join(root, name)); err != nil {
+			t.Skipf("hard links are not supported: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want []string
+	}{
+		{
+			name: "name order",
+			want: []string{
+				"root",
+				"├── a",
+				"│   └── x  [3 links]",
+				"├── b  [3 links, counted once]",
+				"├── c  [3 links, counted once]",
+				"└── d",
+			},
+		},
+		{
+			// Первой считается ссылка, выведенная раньше, а не прочитанная
+			name: "reverse order",
+			cfg:  config.Config{SortReverse: true},
+			want: []string{
+				"root",
+				"├── d",
+				"├── c  [3 links]",
+				"├── b  [3 links, counted once]",
+				"└── a",
+				"    └── x  [3 links, counted once]",
+			},
+		},
+		{
+			// Скрытые сводкой ссылки учитываются после показанных
+			name: "hidden by max-children",
+			cfg:  config.Config{MaxChildren: 2},
+			want: []string{
+				"root",
+				"├── a",
+				"│   └── x  [3 links]",
+				"├── b  [3 links, counted once]",
+				"└── … 2 more files (1 B)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := WalkDirWithContext(context.Background(), root, &tt.cfg, false)
+			if err != nil {
+				t.Fatalf("WalkDirWithContext: %v", err)
+			}
+			var walkedNodes []*Node
+			_ = result.Root.Walk(func(node *Node) error {
+				walkedNodes = append(walkedNodes, node)
+				return nil
+			})
+			walked := linkLines(walkedNodes)
+			if !slices.Equal(walked, tt.want) {
+				t.Errorf("walk lines:\n%q\nwant:\n%q", walked, tt.want)
+			}
+
+			// Строки собираются в момент вывода: дети потом освобождаются
+			var streamed []string
+			for node, err := range NewStream(context.Background(), root, &tt.cfg).Nodes() {
+				if err != nil {
+					t.Fatalf("stream: %v", err)
+				}
+				streamed = append(streamed, linkLines([]*Node{node})...)
+			}
+			if !slices.Equal(streamed, walked) {
+				t.Errorf("stream lines:\n%q\nwalk lines:\n%q", streamed, walked)
+			}
+		})
+	}
+}
@@ -2,13 +2,24 @@ package tree
 
 import (
 	"context"
+	"errors"
+	"io"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/massonsky/gotree/internal/archive"
 	"github.com/massonsky/gotree/internal/config"
+	"github.com/massonsky/gotree/internal/filter"
+	"github.com/massonsky/gotree/internal/gitstatus"
+	"github.com/massonsky/gotree/internal/ignore"
 	"github.com/massonsky/gotree/internal/logger"
 	"github.com/massonsky/gotree/internal/metrics"
 	"github.com/massonsky/gotree/internal/types"
@@ -18,7 +29,59 @@ import (
 	"github.com/schollz/progressbar/v3"
 )
 
-// WalkDirWithContext обходит директорию с прогрессом в реальном времени
+// walker хранит состояние одного обхода. В параллельном режиме
+// директории читаются несколькими горутинами, но каждая горутина
+// пишет только в детей своего узла, поэтому порядок детерминирован.
+type walker struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	cfg    *config.Config
+	ignore []glob.Glob
+	bar    *progressbar.ProgressBar
+
+	// Отбор записей: --where, --pattern и --regex (см. selected)
+	where     *filter.Expr
+	include   []includePattern
+	includeRe []*regexp.Regexp
+	filtering bool // задан хотя бы один --pattern или --regex
+
+	// Правила .gitignore сопоставляются с путями от корня репозитория,
+	// ignorePrefix — путь корня обхода относительно него
+	ignorePrefix string
+
+	sem chan struct{} // nil — последовательный обход
+	wg  sync.WaitGroup
+
+	errOnce sync.Once
+	err     error
+
+	errMu  sync.Mutex
+	errors []ScanError // ошибки отдельных путей, не прервавшие обход
+
+	busy atomic.Int64 // суммарное время чтения директорий всеми воркерами, нс
+
+	mounts mountTable
+
+	// История git по путям от корня репозитория, historyPrefix — путь
+	// корня обхода в нём (nil, если история не нужна)
+	history       *gitstatus.History
+	historyPrefix string
+
+	closeMu sync.Mutex
+	closers []io.Closer // открытые архивы, закрываются вместе с результатом
+}
+
+// dirTask директория, которую предстоит прочитать: узел дерева, место
+// чтения (для раскрытого архива — его корень) и действующие правила
+type dirTask struct {
+	node     *Node
+	loc      location
+	rules    *ignore.Matcher
+	included bool // директория совпала с --pattern при --matchdirs, дети показываются все
+}
+
+// WalkDirWithContext обходит директорию с прогрессом в реальном времени.
+// Если root — архив (.zip, .jar, .tar, .tar.gz), обходится его содержимое.
 func WalkDirWithContext(
 	ctx context.Context,
 	root string,
@@ -42,107 +105,602 @@ func WalkDirWithContext(
 		ctx = ui.WithCancel(ctx, bar)
 	}
 
-	var entries []types.Entry
+	w := newWalker(ctx, cfg, bar)
+	defer w.cancel()
 
 	// Добавляем корневой элемент
-	rootInfo, err := os.Stat(root)
+	rootNode, task, err := w.openRoot(root)
 	if err != nil {
 		return WalkResult{}, err
 	}
-	entries = append(entries, types.Entry{
+	return w.run(rootNode, task, root, startTime)
+}
+
+// WalkFS обходит директорию root внутри произвольной файловой системы,
+// например fstest.MapFS. Правила .gitignore выше root не читаются.
+func WalkFS(ctx context.Context, fsys fs.FS, root string, cfg *config.Config) (WalkResult, error) {
+	startTime := time.Now()
+
+	w := newWalker(ctx, cfg, nil)
+	defer w.cancel()
+
+	rootNode, task, err := w.openRootFS(fsys, root)
+	if err != nil {
+		return WalkResult{}, err
+	}
+	return w.run(rootNode, task, root, startTime)
+}
+
+// openRootFS создаёт корневой узел для пути внутри файловой системы
+// и задачу чтения его детей (nil для файла)
+func (w *walker) openRootFS(fsys fs.FS, root string) (*Node, *dirTask, error) {
+	info, err := fs.Stat(fsys, root)
+	if err != nil {
+		return nil, nil, err
+	}
+	rootNode := NewNode(types.Entry{
+		Path:  root,
+		Info:  info,
+		Depth: 0,
+	}, nil)
+	rootNode.src = location{fsys: fsys, name: root}
+
+	if !info.IsDir() {
+		return rootNode, nil, nil
+	}
+	return rootNode, &dirTask{node: rootNode, loc: rootNode.src}, nil
+}
+
+// openRoot создаёт корневой узел для пути на диске и задачу чтения
+// его детей (nil для обычного файла). Архив в корне раскрывается
+// всегда, без --into-archives.
+func (w *walker) openRoot(root string) (*Node, *dirTask, error) {
+	rootInfo, err := os.Stat(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	rootNode := NewNode(types.Entry{
 		Path:  filepath.Base(root),
 		Info:  rootInfo,
 		Depth: 0,
+	}, nil)
+	if rootInfo.IsDir() {
+		rootNode.src = location{fsys: osFS{dir: root}, name: "."}
+	} else {
+		rootNode.src = location{fsys: osFS{dir: filepath.Dir(root)}, name: filepath.Base(root)}
+	}
+	if w.needsGitHistory() {
+		w.loadGitHistory(rootNode)
+	}
+	w.detectType(&rootNode.Entry, rootNode.src)
+
+	if rootInfo.IsDir() {
+		task := &dirTask{node: rootNode, loc: rootNode.src}
+		if w.cfg.GitIgnore {
+			task.rules = w.initGitIgnore(root)
+		}
+		return rootNode, task, nil
+	}
+
+	if !archive.IsArchive(root) {
+		return rootNode, nil, nil
+	}
+	loc, err := w.openArchive(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	rootNode.Archive = true
+	return rootNode, &dirTask{node: rootNode, loc: loc}, nil
+}
+
+// run обходит дерево от корня и собирает результат
+func (w *walker) run(rootNode *Node, task *dirTask, root string, startTime time.Time) (WalkResult, error) {
+	logger.Debugf("Walking %s with %d worker(s)", root, w.workers())
+	if task != nil {
+		w.walkDir(*task)
+		w.wg.Wait()
+	}
+
+	if w.err != nil {
+		if w.err == context.Canceled {
+			logger.Warn("Directory walk cancelled by user")
+		} else {
+			logger.Errorf("Directory walk failed: %v", w.err)
+		}
+		_ = closeAll(w.closers)
+		return WalkResult{}, w.err
+	}
+
+	if w.cfg.GitStatus || w.cfg.GitChangedOnly {
+		w.markGitStatus(rootNode)
+	}
+	rootNode.prune(w.cfg.Prune)
+	// Пометки до сортировки дают размеры для --sort size, после неё
+	// первая ссылка выбирается в порядке вывода, как в потоке
+	rootNode.markHardLinks()
+	rootNode.Aggregate(sizeMode(w.cfg))
+	rootNode.Sort(sortOptions(w.cfg))
+	if rootNode.markHardLinks() {
+		rootNode.Aggregate(sizeMode(w.cfg))
+	}
+	if w.cfg.Hash {
+		scanErrs, err := HashFiles(w.ctx, rootNode, w.cfg)
+		if err == nil {
+			err = w.addErrors(scanErrs)
+		}
+		if err != nil {
+			_ = closeAll(w.closers)
+			return WalkResult{}, err
+		}
+	}
+	if w.cfg.Loc {
+		scanErrs, err := CountLines(w.ctx, rootNode, w.cfg)
+		if err == nil {
+			err = w.addErrors(scanErrs)
+		}
+		if err != nil {
+			_ = closeAll(w.closers)
+			return WalkResult{}, err
+		}
+	}
+
+	// Воркеры добавляют ошибки в произвольном порядке
+	sort.Slice(w.errors, func(i, j int) bool {
+		return w.errors[i].Path < w.errors[j].Path
 	})
+	entries := rootNode.Flatten()
+	mets := metrics.Collect(entries, startTime)
+	mets.TotalSize = rootNode.Size
+	mets.Errors = len(w.errors)
+	mets.Workers = w.workers()
+	if mets.ScanDuration > 0 {
+		mets.SpeedUp = float64(w.busy.Load()) / float64(mets.ScanDuration)
+	}
+	logger.Infof("Found %d entries in %s", len(entries)-1, root)
+	if len(w.errors) > 0 {
+		logger.Warnf("%d path(s) could not be read", len(w.errors))
+	}
+	// Метрики уже посчитаны по всему дереву, скрывается только вывод
+	if w.cfg.MaxChildren > 0 {
+		rootNode.limitChildren(w.cfg.MaxChildren)
+		entries = rootNode.Flatten()
+	}
+
+	return WalkResult{
+		Entries: entries,
+		Root:    rootNode,
+		Errors:  w.errors,
+		Metrics: mets,
+		closers: w.closers,
+	}, nil
+}
+
+func WalkDir(root string, cfg *config.Config) ([]types.Entry, error) {
+	result, err := WalkDirWithContext(context.Background(), root, cfg, true)
+	if err != nil {
+		return nil, err
+	}
+	return result.Entries, nil
+}
+
+// sortOptions читает параметры сортировки из конфига. Неизвестный режим
+// не прерывает обход: CLI проверяет флаг заранее, а в файле конфига
+// опечатка не должна ломать вывод.
+func sortOptions(cfg *config.Config) SortOptions {
+	mode, err := ParseSortMode(cfg.SortBy)
+	if err != nil {
+		logger.Warnf("%v, falling back to %s", err, SortName)
+		mode = SortName
+	}
+	return SortOptions{
+		Mode:      mode,
+		DirsFirst: cfg.DirsFirst,
+		Reverse:   cfg.SortReverse,
+	}
+}
+
+// sizeMode читает режим подсчёта размеров из конфига
+func sizeMode(cfg *config.Config) SizeMode {
+	mode, err := ParseSizeMode(cfg.SizeMode)
+	if err != nil {
+		logger.Warnf("%v, falling back to %s", err, SizeApparent)
+		mode = SizeApparent
+	}
+	return mode
+}
+
+func newWalker(ctx context.Context, cfg *config.Config, bar *progressbar.ProgressBar) *walker {
+	w := &walker{cfg: cfg, bar: bar}
+	w.ctx, w.cancel = context.WithCancel(ctx)
 
-	// Основной обход (один проход!)
-	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+	// Компилируем шаблоны один раз, а не для каждого файла
+	for _, pattern := range cfg.IgnorePatterns {
+		g, err := glob.Compile(pattern)
 		if err != nil {
-			return err
+			logger.Warnf("Invalid ignore pattern %q: %v", pattern, err)
+			continue
 		}
+		w.ignore = append(w.ignore, g)
+	}
+	w.initSelection(cfg)
+
+	// Корневая горутина тоже работает, поэтому семафор на одно место меньше
+	if cfg.Jobs > 1 {
+		w.sem = make(chan struct{}, cfg.Jobs-1)
+	}
+	return w
+}
+
+// workers возвращает число одновременно читающих горутин
+func (w *walker) workers() int {
+	return cap(w.sem) + 1
+}
+
+// fail запоминает первую ошибку и останавливает остальных воркеров
+func (w *walker) fail(err error) {
+	w.errOnce.Do(func() {
+		w.err = err
+		w.cancel()
+	})
+}
+
+// report запоминает ошибку пути и привязывает её к узлу, если он есть.
+// В режиме --strict первая же ошибка прерывает обход.
+func (w *walker) report(node *Node, relPath, op string, err error) {
+	scanErr := newScanError(relPath, op, err)
+	if node != nil {
+		node.Err = scanErr
+	}
+
+	w.errMu.Lock()
+	w.errors = append(w.errors, *scanErr)
+	w.errMu.Unlock()
+
+	logger.Warnf("Scan error: %v", scanErr)
+	if w.cfg.Strict {
+		w.fail(scanErr)
+	}
+}
+
+// addErrors добавляет ошибки этапов после обхода (--hash, --loc), которые
+// уже привязаны к узлам. В режиме --strict первая из них прерывает обход.
+func (w *walker) addErrors(scanErrs []ScanError) error {
+	w.errors = append(w.errors, scanErrs...)
+	if w.cfg.Strict && len(scanErrs) > 0 {
+		return &scanErrs[0]
+	}
+	return nil
+}
 
+// walkDir читает директорию и рекурсивно спускается в поддиректории
+func (w *walker) walkDir(task dirTask) {
+	if err := w.ctx.Err(); err != nil {
+		w.fail(err)
+		return
+	}
+
+	start := time.Now()
+	subdirs, err := w.readDir(task)
+	w.busy.Add(int64(time.Since(start)))
+	if err != nil {
+		w.fail(err)
+		return
+	}
+
+	for _, sub := range subdirs {
+		w.spawn(sub)
+	}
+}
+
+// spawn отдаёт директорию свободному воркеру или читает её сама,
+// если все воркеры заняты (так пул не может заблокироваться)
+func (w *walker) spawn(task dirTask) {
+	if w.sem != nil {
 		select {
-		case <-ctx.Done():
-			logger.Warn("Directory walk cancelled by user")
-			return ctx.Err()
+		case w.sem <- struct{}{}:
+			w.wg.Add(1)
+			go func() {
+				defer w.wg.Done()
+				defer func() { <-w.sem }()
+				w.walkDir(task)
+			}()
+			return
 		default:
 		}
+	}
+	w.walkDir(task)
+}
 
-		if path == root {
-			return nil
-		}
+// readDir добавляет к узлу отфильтрованных детей и возвращает
+// поддиректории (и раскрываемые архивы), в которые нужно спуститься
+func (w *walker) readDir(task dirTask) ([]dirTask, error) {
+	dir := task.node
 
-		info, err := d.Info()
-		if err != nil {
-			return err
+	// ReadDir возвращает то, что успел прочитать до ошибки
+	dirEntries, err := fs.ReadDir(task.loc.fsys, task.loc.name)
+	if err != nil {
+		w.report(dir, dir.Path, OpReadDir, err)
+	}
+	if w.cfg.FileLimit > 0 && !dir.IsRoot() && len(dirEntries) > w.cfg.FileLimit {
+		dir.OverLimit = len(dirEntries)
+		return nil, nil
+	}
+
+	dirRel := ""
+	if !dir.IsRoot() {
+		dirRel = dir.Path
+	}
+	rules := task.rules
+	if w.cfg.GitIgnore {
+		rules = w.loadIgnoreFiles(w.matchPath(dirRel), task.loc, rules)
+	}
+
+	depth := dir.Depth + 1
+	canDescend := w.cfg.MaxDepth <= 0 || depth < w.cfg.MaxDepth
+	var subdirs []dirTask
+	for _, d := range dirEntries {
+		if err := w.ctx.Err(); err != nil {
+			return nil, err
 		}
 
 		// Скрытые файлы
-		if !cfg.ShowHiddenFiles && strings.HasPrefix(d.Name(), ".") {
-			if d.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
+		if !w.cfg.ShowHiddenFiles && strings.HasPrefix(d.Name(), ".") {
+			continue
 		}
 
 		// Глубина
-		relPath, _ := filepath.Rel(root, path)
-		depth := len(strings.Split(relPath, string(filepath.Separator)))
-		if cfg.MaxDepth > 0 && depth > cfg.MaxDepth {
-			if d.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
+		if w.cfg.MaxDepth > 0 && depth > w.cfg.MaxDepth {
+			continue
 		}
 
 		// Игнорирование
-		relPathForMatch := filepath.ToSlash(relPath)
-		for _, pattern := range cfg.IgnorePatterns {
-			g, err := glob.Compile(pattern)
-			if err != nil {
+		relPath := filepath.Join(dirRel, d.Name())
+		if w.isIgnored(relPath) {
+			continue
+		}
+		if w.cfg.GitIgnore {
+			if d.Name() == ".git" || rules.Match(w.matchPath(relPath), d.IsDir()) {
 				continue
 			}
-			if g.Match(relPathForMatch) {
-				if d.IsDir() {
-					return filepath.SkipDir
-				}
-				return nil
-			}
 		}
 
-		// Добавляем запись
-		entries = append(entries, types.Entry{
+		entry := types.Entry{
 			Path:  relPath,
-			Info:  info,
 			Depth: depth,
-		})
+		}
+		loc := task.loc.join(d.Name())
+		descend := d.IsDir()
+
+		var op string
+		info, err := d.Info()
+		if err != nil {
+			// Показываем запись с пометкой об ошибке, но не спускаемся в неё
+			entry.Info = placeholderInfo{d}
+			op, descend = OpLstat, false
+		} else {
+			entry.Info = info
+			if info.Mode()&fs.ModeSymlink != 0 {
+				op = OpReadlink
+				descend, err = w.resolveSymlink(&entry, dir, loc)
+			}
+			if entry.Info.IsDir() && !w.checkMount(&entry, dir, loc) {
+				descend = false
+			}
+		}
+
+		if err == nil {
+			w.detectType(&entry, loc)
+		}
+		if w.history != nil {
+			if _, onDisk := loc.diskPath(); onDisk {
+				w.addHistory(&entry, path.Join(w.historyPrefix, filepath.ToSlash(relPath)))
+			}
+		}
+
+		// Не прошедшие отбор директории читаются: совпадения могут найтись глубже
+		show, includeAll := w.selected(entry, task.included)
+		if !show && !descend {
+			continue
+		}
+
+		// Добавляем узел к родителю
+		node := NewNode(entry, dir)
+		node.src = loc
+		node.ancestorOnly = !show
+		if err != nil {
+			w.report(node, relPath, op, err)
+		}
+		if descend && canDescend {
+			subdirs = append(subdirs, dirTask{node: node, loc: loc, rules: rules, included: includeAll})
+		} else if canDescend && err == nil && w.isArchive(node) {
+			if sub, ok := w.expandArchive(node); ok {
+				subdirs = append(subdirs, sub)
+			}
+		}
 
 		// Обновляем прогресс в реальном времени
-		if progressEnabled && bar != nil {
-			bar.Add(1)
+		if w.bar != nil {
+			_ = w.bar.Add(1)
 		}
+	}
 
-		return nil
-	})
+	return subdirs, nil
+}
 
+// isArchive сообщает, нужно ли раскрыть файл как архив (--into-archives).
+// Раскрываются только архивы на диске, вложенные архивы остаются файлами.
+func (w *walker) isArchive(node *Node) bool {
+	if !w.cfg.IntoArchives || !node.Info.Mode().IsRegular() {
+		return false
+	}
+	_, onDisk := node.src.diskPath()
+	return onDisk && archive.IsArchive(node.Name())
+}
+
+// expandArchive открывает архив и возвращает задачу чтения его корня.
+// Правила .gitignore внутри архива начинаются заново.
+func (w *walker) expandArchive(node *Node) (dirTask, bool) {
+	filename, _ := node.src.diskPath()
+	loc, err := w.openArchive(filename)
 	if err != nil {
-		logger.Errorf("Directory walk failed: %v", err)
-		return WalkResult{}, err
+		w.report(node, node.Path, OpArchive, err)
+		return dirTask{}, false
 	}
+	node.Archive = true
+	return dirTask{node: node, loc: loc}, true
+}
 
-	mets := metrics.Collect(entries, startTime)
-	logger.Infof("Found %d entries in %s", len(entries)-1, root)
+// openArchive открывает архив на диске и запоминает его для закрытия
+func (w *walker) openArchive(filename string) (location, error) {
+	fsys, closer, err := archive.Open(filename)
+	if err != nil {
+		return location{}, err
+	}
+	w.closeMu.Lock()
+	w.closers = append(w.closers, closer)
+	w.closeMu.Unlock()
+	return location{fsys: fsys, name: "."}, nil
+}
 
-	return WalkResult{
-		Entries: entries,
-		Metrics: mets,
-	}, nil
+// resolveSymlink заполняет цель ссылки, а в режиме --follow-symlinks
+// подменяет информацию о самой ссылке информацией о цели. Возвращает
+// true, если в цель нужно спуститься, и ошибку чтения самой ссылки.
+// Циклы определяются по inode: если цель совпадает с одним из предков,
+// ссылка не раскрывается.
+func (w *walker) resolveSymlink(entry *types.Entry, parent *Node, link location) (bool, error) {
+	target, linkErr := link.readLink()
+	if linkErr != nil || target == "" {
+		target = "?"
+	}
+	entry.LinkTarget = target
+
+	targetInfo, err := fs.Stat(link.fsys, link.name)
+	if err != nil {
+		entry.Broken = true
+		return false, linkErr
+	}
+	if !w.cfg.FollowSymlinks {
+		return false, linkErr
+	}
+
+	entry.Info = targetInfo
+	if !targetInfo.IsDir() {
+		return false, linkErr
+	}
+	if w.leadsToAncestor(parent, link, targetInfo) {
+		entry.Recursive = true
+		return false, linkErr
+	}
+	return true, linkErr
 }
 
-func WalkDir(root string, cfg *config.Config) ([]types.Entry, error) {
-	result, err := WalkDirWithContext(context.Background(), root, cfg, true)
+// leadsToAncestor сообщает, ведёт ли ссылка в директорию-предка. На диске
+// сравниваются inode, внутри архивов и других fs.FS — пути без ссылок:
+// их FileInfo os.SameFile не понимает.
+func (w *walker) leadsToAncestor(parent *Node, link location, targetInfo fs.FileInfo) bool {
+	if _, onDisk := link.diskPath(); onDisk {
+		for a := parent; a != nil; a = a.Parent {
+			if os.SameFile(a.Info, targetInfo) {
+				return true
+			}
+		}
+		return false
+	}
+
+	real := realName(link.fsys, link.name)
+	// Выше раскрытого архива лежит другая файловая система
+	for a := parent; a != nil && !(a.Archive && a.src.name != "."); a = a.Parent {
+		if realName(link.fsys, a.src.name) == real {
+			return true
+		}
+	}
+	return false
+}
+
+// isIgnored проверяет относительный путь по шаблонам --ignore
+func (w *walker) isIgnored(relPath string) bool {
+	relPathForMatch := filepath.ToSlash(relPath)
+	for _, g := range w.ignore {
+		if g.Match(relPathForMatch) {
+			return true
+		}
+	}
+	return false
+}
+
+// initGitIgnore собирает правила, действующие выше корня обхода:
+// глобальный файл исключений git, .git/info/exclude и .gitignore
+// родительских директорий внутри того же репозитория
+func (w *walker) initGitIgnore(root string) *ignore.Matcher {
+	var rules *ignore.Matcher
+	if global := ignore.GlobalExcludesFile(); global != "" {
+		rules = w.loadIgnoreFile(global, "", rules)
+	}
+
+	repo := ignore.FindRepoRoot(root)
+	if repo == "" || repo == root {
+		// Правила корня обхода прочитает readDir
+		return rules
+	}
+
+	prefix, err := filepath.Rel(repo, root)
 	if err != nil {
-		return nil, err
+		return rules
 	}
-	return result.Entries, nil
+	w.ignorePrefix = filepath.ToSlash(prefix)
+
+	rules = w.loadIgnoreFile(filepath.Join(repo, ignore.RepoExcludeFile), "", rules)
+	dir, base := repo, ""
+	parts := strings.Split(w.ignorePrefix, "/")
+	for i := 0; i < len(parts); i++ {
+		for _, name := range ignore.PerDirFiles {
+			rules = w.loadIgnoreFile(filepath.Join(dir, name), base, rules)
+		}
+		dir = filepath.Join(dir, parts[i])
+		base = path.Join(base, parts[i])
+	}
+	return rules
+}
+
+// loadIgnoreFiles добавляет правила из файлов, лежащих в директории
+func (w *walker) loadIgnoreFiles(base string, dir location, rules *ignore.Matcher) *ignore.Matcher {
+	if _, err := fs.Stat(dir.fsys, path.Join(dir.name, ".git")); err == nil {
+		rules = w.loadIgnoreFS(dir.join(filepath.ToSlash(ignore.RepoExcludeFile)), base, rules)
+	}
+	for _, name := range ignore.PerDirFiles {
+		rules = w.loadIgnoreFS(dir.join(name), base, rules)
+	}
+	return rules
+}
+
+func (w *walker) loadIgnoreFS(file location, base string, rules *ignore.Matcher) *ignore.Matcher {
+	patterns, err := ignore.ParseFS(file.fsys, file.name)
+	if err != nil {
+		logger.Warnf("Cannot read ignore file %s: %v", file.name, err)
+		return rules
+	}
+	return rules.Child(base, patterns)
+}
+
+func (w *walker) loadIgnoreFile(filename, base string, rules *ignore.Matcher) *ignore.Matcher {
+	patterns, err := ignore.ParseFile(filename)
+	if err != nil {
+		logger.Warnf("Cannot read ignore file %s: %v", filename, err)
+		return rules
+	}
+	return rules.Child(base, patterns)
+}
+
+// matchPath переводит путь от корня обхода в путь для правил .gitignore
+func (w *walker) matchPath(relPath string) string {
+	return path.Join(w.ignorePrefix, filepath.ToSlash(relPath))
+}
+
+// closeAll закрывает открытые архивы
+func closeAll(closers []io.Closer) error {
+	var errs []error
+	for _, c := range closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
 }
@@ -3,14 +3,13 @@ package tui
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
 	"github.com/massonsky/gotree/internal/config"
 	"github.com/massonsky/gotree/internal/tree"
 
-	"github.com/massonsky/gotree/internal/types"
-
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
@@ -20,49 +19,43 @@ import (
 
 // DirEntry — элемент списка для Bubble Tea
 type DirEntry struct {
-	types.Entry
+	*tree.Node
 	path string
+	top  *tree.Node // верх показанного поддерева
 }
 
 func (d DirEntry) Title() string {
-	if d.Depth == 0 {
-		return filepath.Base(d.path) + "/"
+	// Колонка состояния git, если оно запрошено (--git-status)
+	var git string
+	if d.Git != "" {
+		git = d.Git + " "
 	}
-
-	// Символы для рисования дерева
-	const (
-		vLine   = "│"
-		hLine   = "─"
-		cornerR = "├"
-		cornerB = "└"
-		space   = " "
-	)
-
-	prefix := ""
-	for i := 1; i < d.Depth; i++ {
-		prefix += vLine + space + space + space
+	if d.Node == d.top {
+		return git + filepath.Base(d.path) + "/"
 	}
 
-	// Определяем, является ли элемент последним в своей директории
-	// Это сложно без хранения структуры дерева — пока упростим:
-	// Всегда используем "├──", кроме последнего элемента (если знаете его индекс)
-	// Для простоты — просто добавим "├──" на всех уровнях, кроме корня
-
-	prefix += cornerR + hLine + hLine + hLine
-
-	name := filepath.Base(d.path)
-	if d.Info.IsDir() {
+	name := d.Name()
+	if d.IsDir() {
 		name += "/"
 	}
 
-	return prefix + name
+	return git + d.PrefixUnder(d.top, tree.BoxPrefixStyle) + name + d.Suffix()
 }
 
 func (d DirEntry) Description() string {
-	if d.Info.IsDir() {
-		return "directory"
+	if d.Omitted > 0 {
+		return fmt.Sprintf("hidden by --max-children · %d bytes in %d files", d.Size, d.FileCount)
+	}
+	if d.Broken {
+		return "broken symlink"
+	}
+	if d.IsDir() {
+		return fmt.Sprintf("directory · %d bytes in %d files", d.Size, d.FileCount)
 	}
-	return fmt.Sprintf("%d bytes", d.Info.Size())
+	if d.MIME != "" {
+		return fmt.Sprintf("%s · %d bytes", d.MIME, d.Size)
+	}
+	return fmt.Sprintf("%d bytes", d.Size)
 }
 
 func (d DirEntry) FilterValue() string { return d.path }
@@ -71,11 +64,14 @@ func (d DirEntry) FilterValue() string { return d.path }
 type Model struct {
 	ctx          context.Context
 	cfg          *config.Config
-	rootPath     string
+	rootPath     string // путь корня обхода на диске
+	result       tree.WalkResult
+	top          *tree.Node // показанное поддерево: корень или директория в архиве
 	entries      []list.Item
 	list         list.Model
 	viewport     viewport.Model
 	showFileView bool
+	static       bool // дерево построено не обходом диска, навигация не пересканирует
 	err          error
 }
 
@@ -86,28 +82,26 @@ func NewModel(ctx context.Context, cfg *config.Config, rootPath string) (Model,
 	if err != nil {
 		return Model{}, err
 	}
+	return newModel(ctx, cfg, rootPath, walkResult, walkResult.Root), nil
+}
 
-	// Преобразуем записи
+// newModel строит модель по готовому результату обхода, показывая
+// поддерево top
+func newModel(ctx context.Context, cfg *config.Config, rootPath string, walkResult tree.WalkResult, top *tree.Node) Model {
+	// Преобразуем узлы дерева в элементы списка
 	var items []list.Item
-	for _, entry := range walkResult.Entries {
-		fullPath := entry.Path
-		if entry.Depth == 0 {
-			fullPath = rootPath
-		} else {
-			fullPath = filepath.Join(rootPath, entry.Path)
-		}
-
-		// Создаём DirEntry и добавляем в список
-		item := DirEntry{
-			Entry: entry,
-			path:  fullPath,
-		}
-		items = append(items, item) // ← Важно: добавляем именно DirEntry, а не types.Entry
-	}
+	_ = top.Walk(func(node *tree.Node) error {
+		items = append(items, DirEntry{
+			Node: node,
+			path: nodePath(rootPath, node),
+			top:  top,
+		})
+		return nil
+	})
 
 	// Создаём список
 	l := list.New(items, list.NewDefaultDelegate(), 0, 0)
-	l.Title = fmt.Sprintf("📁 %s", rootPath)
+	l.Title = fmt.Sprintf("📁 %s", nodePath(rootPath, top))
 	l.SetShowStatusBar(true)
 	l.SetFilteringEnabled(true)
 	l.Styles.HelpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
@@ -131,10 +125,12 @@ func NewModel(ctx context.Context, cfg *config.Config, rootPath string) (Model,
 		ctx:      ctx,
 		cfg:      cfg,
 		rootPath: rootPath,
+		result:   walkResult,
+		top:      top,
 		entries:  items,
 		list:     l,
 		viewport: vp,
-	}, nil
+	}
 }
 
 // Init инициализация модели
@@ -153,18 +149,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "enter":
 			if !m.showFileView {
 				item, ok := m.list.SelectedItem().(DirEntry)
-				if ok {
-					if item.Info.IsDir() {
-						// Рекурсивно открываем поддиректорию
-						newModel, err := NewModel(m.ctx, m.cfg, item.path)
-						if err != nil {
-							m.err = err
-							return m, tea.Quit
-						}
-						return newModel, nil
-					} else {
+				if ok && item.Omitted == 0 {
+					if item.IsDir() && !item.OnDisk() && !m.static {
+						// Директории внутри архива на диске нет: показываем
+						// поддерево из уже прочитанного архива
+						return m.subtree(item.Node), nil
+					} else if (item.IsDir() || item.Archive) && !m.static {
+						// Рекурсивно открываем поддиректорию или архив
+						return m.open(item.path)
+					} else if !item.IsDir() {
 						// Показываем содержимое файла
-						content, err := os.ReadFile(item.path)
+						content, err := readNode(item.Node)
 						if err != nil {
 							m.err = err
 							return m, nil
@@ -180,16 +175,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "esc", "backspace":
 			if m.showFileView {
 				m.showFileView = false
-			} else {
+			} else if !m.static && !m.top.IsRoot() {
+				// Поднимаемся внутри архива, не пересканируя
+				return m.subtree(m.top.Parent), nil
+			} else if !m.static {
 				// Возвращаемся на уровень выше
 				parent := filepath.Dir(m.rootPath)
 				if parent != m.rootPath {
-					newModel, err := NewModel(m.ctx, m.cfg, parent)
-					if err != nil {
-						m.err = err
-						return m, tea.Quit
-					}
-					return newModel, nil
+					return m.open(parent)
 				}
 			}
 		}
@@ -219,6 +212,34 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// open сканирует путь на диске и переключается на новую модель.
+// Прежний результат закрывается, чтобы не держать открытыми архивы.
+func (m Model) open(path string) (tea.Model, tea.Cmd) {
+	newModel, err := NewModel(m.ctx, m.cfg, path)
+	if err != nil {
+		m.err = err
+		return m, tea.Quit
+	}
+	_ = m.result.Close()
+	return newModel, nil
+}
+
+// subtree показывает поддерево уже прочитанного дерева
+func (m Model) subtree(top *tree.Node) Model {
+	sub := newModel(m.ctx, m.cfg, m.rootPath, m.result, top)
+	sub.static = m.static
+	return sub
+}
+
+// nodePath путь узла для показа: для записей архива — путь архива
+// на диске и путь внутри него
+func nodePath(rootPath string, node *tree.Node) string {
+	if node.IsRoot() {
+		return rootPath
+	}
+	return filepath.Join(rootPath, node.Path)
+}
+
 // View рендеринг интерфейса
 func (m Model) View() string {
 	if m.err != nil {
@@ -236,7 +257,7 @@ func (m Model) View() string {
 	header := lipgloss.NewStyle().
 		Bold(true).
 		Foreground(lipgloss.Color("63")).
-		Render(fmt.Sprintf("📁 %s — %d items", m.rootPath, len(m.entries)))
+		Render(fmt.Sprintf("📁 %s — %d items", nodePath(m.rootPath, m.top), len(m.entries)))
 
 	return lipgloss.JoinVertical(lipgloss.Top,
 		header,
@@ -252,9 +273,34 @@ func Run(ctx context.Context, cfg *config.Config, rootPath string) error {
 	}
 
 	p := tea.NewProgram(model, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
-		return err
+	final, err := p.Run()
+	// Закрываем результат, открытый последним (архивы текущей модели)
+	if last, ok := final.(Model); ok {
+		_ = last.result.Close()
+	} else {
+		_ = model.result.Close()
 	}
+	return err
+}
 
-	return nil
+// RunResult запускает TUI для готового дерева, например построенного
+// из списка путей. Клавиатура читается из терминала, даже если
+// список пришёл через stdin.
+func RunResult(ctx context.Context, cfg *config.Config, result tree.WalkResult) error {
+	model := newModel(ctx, cfg, result.Root.Path, result, result.Root)
+	model.static = true
+
+	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithInputTTY())
+	_, err := p.Run()
+	return err
+}
+
+// readNode читает файл через узел дерева, поэтому работает и для записей архивов
+func readNode(node *tree.Node) ([]byte, error) {
+	f, err := node.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
 }
@@ -0,0 +1,107 @@
+package tui
+
+import (
+	"archive/tar"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/massonsky/gotree/internal/config"
+)
+
+// writeTar создаёт архив с файлами и их директориями
+func writeTar(t *testing.T, filename string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tw := tar.NewWriter(f)
+	for name, data := range files {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// press выбирает элемент с заголовком title и нажимает клавишу
+func press(t *testing.T, m Model, title string, key tea.KeyType) Model {
+	t.Helper()
+	if title != "" {
+		found := false
+		for i, item := range m.list.Items() {
+			if item.(DirEntry).Title() == title {
+				m.list.Select(i)
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("no item %q in %q", title, titles(m))
+		}
+	}
+	next, _ := m.Update(tea.KeyMsg{Type: key})
+	m = next.(Model)
+	if m.err != nil {
+		t.Fatalf("error after key %v: %v", key, m.err)
+	}
+	return m
+}
+
+func titles(m Model) []string {
+	var out []string
+	for _, item := range m.list.Items() {
+		out = append(out, item.(DirEntry).Title())
+	}
+	return out
+}
+
+func TestArchiveNavigation(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "release.tar")
+	writeTar(t, archivePath, map[string]string{
+		"app/README.md":   "readme",
+		"app/lib/util.go": "package lib",
+	})
+
+	m, err := NewModel(context.Background(), &config.Config{}, archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Директории архива открываются из уже прочитанного дерева
+	m = press(t, m, "└── app/", tea.KeyEnter)
+	if got := nodePath(m.rootPath, m.top); got != filepath.Join(archivePath, "app") {
+		t.Errorf("top = %q, want the app directory inside the archive", got)
+	}
+	m = press(t, m, "└── lib/", tea.KeyEnter)
+	want := []string{"lib/", "└── util.go"}
+	if got := titles(m); len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("titles = %q, want %q", got, want)
+	}
+
+	// Файл в архиве читается через узел
+	m = press(t, m, "└── util.go", tea.KeyEnter)
+	if !m.showFileView {
+		t.Fatal("file view is not shown")
+	}
+	m = press(t, m, "", tea.KeyEsc)
+
+	// Назад по архиву — без пересканирования, до корня архива
+	m = press(t, m, "", tea.KeyEsc)
+	m = press(t, m, "", tea.KeyEsc)
+	if !m.top.IsRoot() || m.rootPath != archivePath {
+		t.Errorf("top = %q, want the archive root", nodePath(m.rootPath, m.top))
+	}
+	_ = m.result.Close()
+}
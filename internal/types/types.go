@@ -3,13 +3,57 @@ package types
 import (
 	"io"
 	"os"
+	"time"
+
+	"github.com/massonsky/gotree/internal/loc"
 )
 
 // Entry представляет элемент файловой системы
 type Entry struct {
 	Path  string
 	Info  os.FileInfo
-	Depth int // Глубина вложенности для форматирования вывода
+	Depth int   // Глубина вложенности для форматирования вывода
+	Err   error // ошибка чтения записи, обход при этом продолжается
+
+	// Символические ссылки
+	LinkTarget string // цель ссылки как есть, "" — не ссылка
+	Broken     bool   // цель ссылки не существует
+	Recursive  bool   // ссылка ведёт в одного из предков и не раскрывается
+
+	Archive bool // файл архива, содержимое которого показано как поддерево
+
+	// Точки монтирования
+	Mount        string // тип файловой системы ("?" — неизвестен), "" — не точка монтирования
+	MountSkipped bool   // содержимое не читалось: --one-file-system или skip_fs_types
+
+	// Ограничения вывода больших директорий
+	OverLimit int // директория не читалась из-за --filelimit: столько в ней записей
+	Omitted   int // запись-сводка: столько соседей скрыто --max-children
+
+	// Жёсткие ссылки
+	Links       int  // число жёстких ссылок на файл, 0 — неизвестно
+	HardLinkDup bool // файл уже встречался по другой ссылке и не входит в размеры
+
+	Hash string // хэш содержимого вида "sha256:hex", "" — не считался
+
+	// Тип содержимого обычного файла, см. пакет filetype
+	Kind string // image, archive, executable, script, source, text, binary…; "" — не определён
+	MIME string // например "image/png"
+	Lang string // язык или формат: "go", "python", "yaml"
+
+	Loc loc.Counts // строки кода при --loc; для директории — сумма по поддереву
+
+	Git string // состояние в git, как у eza --git: "-M", "N-"; "" — не запрашивалось или вне репозитория
+
+	// История git: для директории — по всем файлам внутри
+	LastCommit time.Time // время последнего коммита, затронувшего запись
+	LastAuthor string    // его автор
+	Commits    int       // число коммитов, 0 — нет в истории или история не читалась
+}
+
+// IsSymlink сообщает, является ли запись символической ссылкой
+func (e Entry) IsSymlink() bool {
+	return e.LinkTarget != ""
 }
 
 // Exporter интерфейс для всех форматов экспорта